@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/batch"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// runBatch applies operations file to the data directory
+func runBatch(args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	file := flags.String("file", "", "file with operations")
+	dir := flags.String("data", "data", "directory to import data from and export it to")
+	mode := flags.String("mode", "all", "all to roll back on the first failure, continue to skip failed lines")
+	reportPath := flags.String("report", "", "file to write CSV report to, stdout by default")
+	feeSchedule := flags.String("fees", "", "file with fee schedule, payments are free without it")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	runMode := batch.AllOrNothing
+	switch *mode {
+	case "all":
+	case "continue":
+		runMode = batch.ContinueOnError
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+
+	input, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	operations, err := batch.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	svc := &wallet.Service{}
+	if _, err := os.Stat(*dir); err == nil {
+		err = svc.Import(*dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *feeSchedule != "" {
+		err = svc.LoadFees(*feeSchedule)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, runErr := batch.NewRunner(svc, runMode).Run(operations)
+	if report == nil {
+		return runErr
+	}
+
+	var out io.Writer = os.Stdout
+	if *reportPath != "" {
+		reportFile, err := os.Create(*reportPath)
+		if err != nil {
+			return err
+		}
+		defer reportFile.Close()
+		out = reportFile
+	}
+
+	err = report.WriteCSV(out)
+	if err != nil {
+		return err
+	}
+
+	if runErr != nil && runMode == batch.AllOrNothing {
+		return runErr
+	}
+
+	if runErr != nil {
+		log.Printf("%d of %d operations failed", report.Failed(), len(report.Results))
+	}
+
+	return svc.Export(*dir)
+}
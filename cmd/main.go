@@ -11,9 +11,32 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		err := run(os.Args[1], os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	testNewTick()
 }
 
+func run(command string, args []string) error {
+	switch command {
+	case "serve":
+		return serve(args)
+	case "shell":
+		return interactive(args)
+	case "batch":
+		return runBatch(args)
+	case "migrate-phones":
+		return migratePhones(args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
 func testNewTick() {
 	ch := newtick()
 	for i := range ch {
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// migratePhones normalizes phones of the data directory and prints collisions
+func migratePhones(args []string) error {
+	flags := flag.NewFlagSet("migrate-phones", flag.ContinueOnError)
+	dir := flags.String("data", "data", "directory to import data from and export it to")
+	dryRun := flags.Bool("dry-run", false, "only print the report")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	svc := &wallet.Service{}
+	err = svc.Import(*dir)
+	if err != nil {
+		return err
+	}
+
+	migration := svc.MigratePhones(*dryRun)
+	for _, change := range migration.Changed {
+		fmt.Fprintf(os.Stdout, "changed\t%d\t%s\t%s\n", change.AccountID, change.From, change.To)
+	}
+
+	for _, accountID := range migration.Invalid {
+		fmt.Fprintf(os.Stdout, "invalid\t%d\n", accountID)
+	}
+
+	for _, collision := range migration.Collisions {
+		fmt.Fprintf(os.Stdout, "collision\t%s\t%v\n", collision.Phone, collision.AccountIDs)
+	}
+
+	if *dryRun || len(migration.Changed) == 0 {
+		return nil
+	}
+
+	return svc.Export(*dir)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/server"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// serve runs HTTP API until SIGINT or SIGTERM
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	dir := flags.String("data", "", "directory to import data from and export it to on shutdown")
+	interval := flags.Duration("schedule-interval", time.Minute, "how often scheduled payments are checked, 0 disables them")
+	operators := flags.String("operators", "", "file with operator prefix table, built-in table by default")
+	rates := flags.String("rates", "", "file with exchange rates, exchanges are refused without it")
+	feeSchedule := flags.String("fees", "", "file with fee schedule, payments are free without it")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	logger := wallet.NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), wallet.LevelInfo)
+	options := []wallet.Option{ wallet.WithLogger(logger) }
+	if *dir != "" {
+		options = append(options, wallet.WithExportDir(*dir))
+	}
+
+	svc, err := wallet.NewService(options...)
+	if err != nil {
+		return err
+	}
+
+	if *dir != "" {
+		err = svc.Load()
+		if err != nil {
+			return err
+		}
+	}
+
+	if *operators != "" {
+		err = svc.LoadOperators(*operators)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *rates != "" {
+		err = svc.LoadRates(*rates)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *feeSchedule != "" {
+		err = svc.LoadFees(*feeSchedule)
+		if err != nil {
+			return err
+		}
+	}
+
+	srv := server.New(svc, *addr, logger)
+	srv.ExportDir = *dir
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<- signals
+		cancel()
+	}()
+
+	if *interval > 0 {
+		scheduler := wallet.NewScheduler(svc, srv.Locker(), *interval)
+		go scheduler.Run(ctx)
+	}
+
+	log.Println("listening on", *addr)
+	err = srv.ListenAndServe(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *dir != "" {
+		// scheduler may still be finishing its run
+		locker := srv.Locker()
+		locker.Lock()
+		defer locker.Unlock()
+
+		return svc.Save()
+	}
+
+	return nil
+}
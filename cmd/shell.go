@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/shell"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// interactive runs operator shell over the data directory
+func interactive(args []string) error {
+	flags := flag.NewFlagSet("shell", flag.ContinueOnError)
+	dir := flags.String("data", "data", "directory to import data from, save exports to it")
+	localeName := flags.String("locale", "en", "how amounts are printed: plain, en, ru or tg, add +symbol for currency symbols")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	locale, err := money.LookupLocale(*localeName)
+	if err != nil {
+		return err
+	}
+
+	svc := &wallet.Service{}
+	if _, err := os.Stat(*dir); err == nil {
+		err = svc.Import(*dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	state, err := rawMode()
+	if err == nil {
+		defer restoreMode(state)
+		out = crlfWriter{ w: os.Stdout }
+	}
+
+	sh := shell.New(svc, *dir, out)
+	sh.Locale = locale
+	editor := shell.NewEditor(os.Stdin, out, sh.Complete)
+
+	return sh.Run(editor)
+}
+
+// rawMode switches terminal to raw mode and returns previous state
+func rawMode() (string, error) {
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = os.Stdin
+	state, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	cmd = exec.Command("stty", "raw", "-echo")
+	cmd.Stdin = os.Stdin
+	err = cmd.Run()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(state)), nil
+}
+
+func restoreMode(state string) {
+	cmd := exec.Command("stty", state)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+}
+
+// crlfWriter translates line feeds for terminals in raw mode
+type crlfWriter struct {
+	w	io.Writer
+}
+
+func (c crlfWriter) Write(p []byte) (int, error) {
+	_, err := c.w.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n")))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
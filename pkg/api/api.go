@@ -0,0 +1,60 @@
+package api
+
+import (
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// IdempotencyKeyHeader - header used to make POST requests safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RegisterAccountRequest - body of POST /accounts
+type RegisterAccountRequest struct {
+	Phone	types.Phone	`json:"phone"`
+}
+
+// DepositRequest - body of POST /accounts/{id}/deposit
+type DepositRequest struct {
+	Amount	types.Money	`json:"amount"`
+}
+
+// PayRequest - body of POST /payments and POST /payments/quote
+type PayRequest struct {
+	AccountID	int64					`json:"accountId"`
+	Amount		types.Money				`json:"amount"`
+	Category	types.PaymentCategory	`json:"category"`
+}
+
+// FavoriteRequest - body of POST /favorites
+type FavoriteRequest struct {
+	PaymentID	string	`json:"paymentId"`
+	Name		string	`json:"name"`
+}
+
+// Account - account in responses, Formatted is the balance written for people
+type Account struct {
+	*types.Account
+	Formatted	string	`json:"formatted"`
+}
+
+// Payment - payment in responses, Formatted is the amount written for people
+type Payment struct {
+	*types.Payment
+	Formatted	string	`json:"formatted"`
+}
+
+// Favorite - favorite in responses, Formatted is the amount written for people
+type Favorite struct {
+	*types.Favorite
+	Formatted	string	`json:"formatted"`
+}
+
+// ErrorBody - JSON error envelope
+type ErrorBody struct {
+	Error	ErrorResponse	`json:"error"`
+}
+
+// ErrorResponse - description of a failed request
+type ErrorResponse struct {
+	Code	string	`json:"code"`
+	Message	string	`json:"message"`
+}
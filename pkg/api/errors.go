@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// Error codes returned in the JSON error body
+const (
+	CodeInvalidRequest		= "invalid_request"
+	CodeNotFound			= "not_found"
+	CodeMethodNotAllowed	= "method_not_allowed"
+	CodeInternal			= "internal_error"
+	CodePhoneRegistered		= "phone_registered"
+	CodeAmountMustBePositive = "amount_must_be_positive"
+	CodeAccountNotFound		= "account_not_found"
+	CodeNotEnoughBalance	= "not_enough_balance"
+	CodePaymentNotFound		= "payment_not_found"
+	CodeFavoriteNotFound	= "favorite_not_found"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeLimitExceeded		= "limit_exceeded"
+	CodeHoldNotFound		= "hold_not_found"
+	CodeHoldNotActive		= "hold_not_active"
+	CodeCaptureExceedsHold	= "capture_exceeds_hold"
+	CodePaymentNotRejectable = "payment_not_rejectable"
+	CodeRefundExceedsPayment = "refund_exceeds_payment"
+	CodePaymentNotRefundable = "payment_not_refundable"
+	CodeDisputeNotFound		= "dispute_not_found"
+	CodePaymentDisputed		= "payment_disputed"
+	CodeInvalidDisputeTransition = "invalid_dispute_transition"
+	CodeScheduleNotFound	= "schedule_not_found"
+	CodeInvalidSchedule		= "invalid_schedule"
+	CodeFavoriteNameTaken	= "favorite_name_taken"
+	CodeInvalidFavoriteName	= "invalid_favorite_name"
+	CodeInvalidFavoriteOrder = "invalid_favorite_order"
+	CodeTemplateNotFound	= "template_not_found"
+	CodeInvalidTemplate		= "invalid_template"
+	CodeInvalidTemplateAmount = "invalid_template_amount"
+	CodeInvalidTemplateParams = "invalid_template_params"
+	CodeAccountFrozen		= "account_frozen"
+	CodeAccountClosed		= "account_closed"
+	CodeAccountNotEmpty		= "account_not_empty"
+	CodeInvalidAccountStatus = "invalid_account_status"
+	CodeInvalidPhone		= "invalid_phone"
+	CodeOperatorNotFound	= "operator_not_found"
+	CodeInvalidTopUpAmount	= "invalid_top_up_amount"
+	CodePocketNotFound		= "pocket_not_found"
+	CodePocketNameTaken		= "pocket_name_taken"
+	CodeInvalidPocketName	= "invalid_pocket_name"
+	CodeNotSameCustomer		= "not_same_customer"
+	CodeMainPocketRequired	= "main_pocket_required"
+	CodeMemberNotFound		= "member_not_found"
+	CodeMemberExists		= "member_exists"
+	CodeNotAccountOwner		= "not_account_owner"
+	CodeCategoryNotAllowed	= "category_not_allowed"
+	CodeUnknownCurrency		= "unknown_currency"
+	CodeCurrencyMismatch	= "currency_mismatch"
+	CodeRateNotFound		= "rate_not_found"
+	CodeExchangeNotFound	= "exchange_not_found"
+	CodeQuoteExpired		= "quote_expired"
+	CodeQuoteNotQuoted		= "quote_not_quoted"
+	CodeSameCurrency		= "same_currency"
+	CodeExchangeTooSmall	= "exchange_too_small"
+	CodeAmountOverflow		= "amount_overflow"
+	CodePaymentNotRepeatable = "payment_not_repeatable"
+)
+
+// ErrInvalidRequest - request body or parameters are malformed
+var ErrInvalidRequest = errors.New("Invalid request")
+
+// ErrIdempotencyKeyReused - the key was already used with another request
+var ErrIdempotencyKeyReused = errors.New("Idempotency key was already used with a different request")
+
+type errorMapping struct {
+	err		error
+	code	string
+	status	int
+}
+
+// errorMappings binds service errors to codes and http statuses
+var errorMappings = []errorMapping {
+	{ err: ErrInvalidRequest,				code: CodeInvalidRequest,		status: http.StatusBadRequest },
+	{ err: ErrIdempotencyKeyReused,			code: CodeIdempotencyKeyReused,	status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPhoneRegistered,		code: CodePhoneRegistered,		status: http.StatusConflict },
+	{ err: wallet.ErrAmountMustBePositive,	code: CodeAmountMustBePositive,	status: http.StatusBadRequest },
+	{ err: wallet.ErrAccountNotFound,		code: CodeAccountNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrNotEnoughBalance,		code: CodeNotEnoughBalance,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPaymentNotFound,		code: CodePaymentNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrFavoriteNotFound,		code: CodeFavoriteNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrLimitExceeded,			code: CodeLimitExceeded,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrHoldNotFound,			code: CodeHoldNotFound,			status: http.StatusNotFound },
+	{ err: wallet.ErrHoldNotActive,			code: CodeHoldNotActive,		status: http.StatusConflict },
+	{ err: wallet.ErrCaptureExceedsHold,	code: CodeCaptureExceedsHold,	status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPaymentNotRejectable,	code: CodePaymentNotRejectable,	status: http.StatusConflict },
+	{ err: wallet.ErrRefundExceedsPayment,	code: CodeRefundExceedsPayment,	status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPaymentNotRefundable,	code: CodePaymentNotRefundable,	status: http.StatusConflict },
+	{ err: wallet.ErrDisputeNotFound,		code: CodeDisputeNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrPaymentDisputed,		code: CodePaymentDisputed,		status: http.StatusConflict },
+	{ err: wallet.ErrInvalidDisputeTransition, code: CodeInvalidDisputeTransition, status: http.StatusConflict },
+	{ err: wallet.ErrScheduleNotFound,		code: CodeScheduleNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrInvalidSchedule,		code: CodeInvalidSchedule,		status: http.StatusBadRequest },
+	{ err: wallet.ErrFavoriteNameTaken,		code: CodeFavoriteNameTaken,	status: http.StatusConflict },
+	{ err: wallet.ErrInvalidFavoriteName,	code: CodeInvalidFavoriteName,	status: http.StatusBadRequest },
+	{ err: wallet.ErrInvalidFavoriteOrder,	code: CodeInvalidFavoriteOrder,	status: http.StatusBadRequest },
+	{ err: wallet.ErrTemplateNotFound,		code: CodeTemplateNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrInvalidTemplate,		code: CodeInvalidTemplate,		status: http.StatusBadRequest },
+	{ err: wallet.ErrInvalidTemplateAmount,	code: CodeInvalidTemplateAmount, status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrInvalidTemplateParams,	code: CodeInvalidTemplateParams, status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrAccountFrozen,			code: CodeAccountFrozen,		status: http.StatusConflict },
+	{ err: wallet.ErrAccountClosed,			code: CodeAccountClosed,		status: http.StatusConflict },
+	{ err: wallet.ErrAccountNotEmpty,		code: CodeAccountNotEmpty,		status: http.StatusConflict },
+	{ err: wallet.ErrInvalidAccountStatus,	code: CodeInvalidAccountStatus,	status: http.StatusConflict },
+	{ err: wallet.ErrInvalidPhone,			code: CodeInvalidPhone,			status: http.StatusBadRequest },
+	{ err: wallet.ErrOperatorNotFound,		code: CodeOperatorNotFound,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrInvalidTopUpAmount,	code: CodeInvalidTopUpAmount,	status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPocketNotFound,		code: CodePocketNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrPocketNameTaken,		code: CodePocketNameTaken,		status: http.StatusConflict },
+	{ err: wallet.ErrInvalidPocketName,		code: CodeInvalidPocketName,	status: http.StatusBadRequest },
+	{ err: wallet.ErrNotSameCustomer,		code: CodeNotSameCustomer,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrMainPocketRequired,	code: CodeMainPocketRequired,	status: http.StatusConflict },
+	{ err: wallet.ErrMemberNotFound,		code: CodeMemberNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrMemberExists,			code: CodeMemberExists,			status: http.StatusConflict },
+	{ err: wallet.ErrNotAccountOwner,		code: CodeNotAccountOwner,		status: http.StatusForbidden },
+	{ err: wallet.ErrCategoryNotAllowed,	code: CodeCategoryNotAllowed,	status: http.StatusForbidden },
+	{ err: wallet.ErrUnknownCurrency,		code: CodeUnknownCurrency,		status: http.StatusBadRequest },
+	{ err: wallet.ErrCurrencyMismatch,		code: CodeCurrencyMismatch,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrRateNotFound,			code: CodeRateNotFound,			status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrExchangeNotFound,		code: CodeExchangeNotFound,		status: http.StatusNotFound },
+	{ err: wallet.ErrQuoteExpired,			code: CodeQuoteExpired,			status: http.StatusConflict },
+	{ err: wallet.ErrQuoteNotQuoted,		code: CodeQuoteNotQuoted,		status: http.StatusConflict },
+	{ err: wallet.ErrSameCurrency,			code: CodeSameCurrency,			status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrExchangeTooSmall,		code: CodeExchangeTooSmall,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrOverflow,				code: CodeAmountOverflow,		status: http.StatusUnprocessableEntity },
+	{ err: wallet.ErrPaymentNotRepeatable,	code: CodePaymentNotRepeatable,	status: http.StatusConflict },
+}
+
+// CodeOf returns error code and http status for the given error
+func CodeOf(err error) (string, int) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return mapping.code, mapping.status
+		}
+	}
+
+	return CodeInternal, http.StatusInternalServerError
+}
+
+// ErrorOf returns service error for the given code, nil if code is unknown
+func ErrorOf(code string) error {
+	for _, mapping := range errorMappings {
+		if mapping.code == code {
+			return mapping.err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+func TestErrorOf_success(t *testing.T) {
+	for _, err := range []error{ wallet.ErrAccountNotFound, wallet.ErrNotEnoughBalance, wallet.ErrPhoneRegistered } {
+		code, _ := CodeOf(err)
+		if ErrorOf(code) != err {
+			t.Errorf("ErrorOf(): wrong error for code %v", code)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// Operation names supported in batch files
+const (
+	OpRegister	= "register"
+	OpDeposit	= "deposit"
+	OpPay		= "pay"
+	OpTransfer	= "transfer"
+	OpReject	= "reject"
+	OpFavorite	= "favorite"
+)
+
+// ErrInvalidBatch - batch file contains invalid lines
+var ErrInvalidBatch = errors.New("Batch contains invalid operations")
+
+// Operation - single line of the batch file
+//
+// Lines are comma separated: operation name, arguments and an optional @label
+// which later lines may use instead of the payment id:
+//
+//	register,+992937452945
+//	deposit,+992937452945,100.00
+//	pay,1,25,auto,@p1
+//	transfer,1,+992937452946,10.50
+//	favorite,@p1,car
+//	reject,@p1
+//
+// Accounts are referenced by id or by phone number, amounts are decimals in the
+// currency of the account.
+type Operation struct {
+	Line	int
+	Name	string
+	Args	[]string
+	Label	string
+}
+
+// LineError - validation error of a single line
+type LineError struct {
+	Line	int
+	Err		error
+}
+
+// Error returns line number with the error
+func (e *LineError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+// ValidationError - all problems found in the batch
+type ValidationError struct {
+	Errors	[]*LineError
+}
+
+// Error returns all line errors
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return ErrInvalidBatch.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns ErrInvalidBatch
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidBatch
+}
+
+// arity - number of arguments of every operation without label
+var arity = map[string]int {
+	OpRegister:	1,
+	OpDeposit:	2,
+	OpPay:		3,
+	OpTransfer:	3,
+	OpReject:	1,
+	OpFavorite:	2,
+}
+
+// Parse reads and validates whole batch, nothing should be executed if it returns an error
+func Parse(r io.Reader) ([]Operation, error) {
+	scanner := bufio.NewScanner(r)
+
+	var operations []Operation
+	var errs []*LineError
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		record, err := csv.NewReader(strings.NewReader(text)).Read()
+		if err != nil {
+			errs = append(errs, &LineError{ Line: line, Err: err })
+			continue
+		}
+
+		operation := Operation {
+			Line:	line,
+			Name:	strings.ToLower(strings.TrimSpace(record[0])),
+		}
+
+		for _, field := range record[1:] {
+			operation.Args = append(operation.Args, strings.TrimSpace(field))
+		}
+
+		count := len(operation.Args)
+		if count > 0 && count > arity[operation.Name] && strings.HasPrefix(operation.Args[count - 1], "@") {
+			operation.Label = operation.Args[count - 1][1:]
+			operation.Args = operation.Args[:count - 1]
+		}
+
+		operations = append(operations, operation)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	err = Validate(operations)
+	if verr, ok := err.(*ValidationError); ok {
+		errs = append(errs, verr.Errors...)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Line < errs[j].Line
+		})
+		return nil, &ValidationError{ Errors: errs }
+	}
+
+	return operations, nil
+}
+
+// Validate checks every operation and collects all errors
+func Validate(operations []Operation) error {
+	var errs []*LineError
+	labels := make(map[string]bool)
+
+	for _, operation := range operations {
+		err := validate(operation, labels)
+		if err != nil {
+			errs = append(errs, &LineError{ Line: operation.Line, Err: err })
+			continue
+		}
+
+		if operation.Label != "" {
+			labels[operation.Label] = true
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{ Errors: errs }
+	}
+
+	return nil
+}
+
+func validate(operation Operation, labels map[string]bool) error {
+	count, ok := arity[operation.Name]
+	if !ok {
+		return fmt.Errorf("unknown operation %q", operation.Name)
+	}
+
+	if len(operation.Args) != count {
+		return fmt.Errorf("%s expects %d arguments, got %d", operation.Name, count, len(operation.Args))
+	}
+
+	if operation.Label != "" {
+		if operation.Name != OpPay && operation.Name != OpTransfer {
+			return fmt.Errorf("label is supported only by %s and %s", OpPay, OpTransfer)
+		}
+
+		if _, ok := labels[operation.Label]; ok {
+			return fmt.Errorf("label @%s is already defined", operation.Label)
+		}
+	}
+
+	switch operation.Name {
+	case OpRegister:
+		if operation.Args[0] == "" {
+			return errors.New("phone is required")
+		}
+	case OpDeposit:
+		err := validateAccount(operation.Args[0])
+		if err != nil {
+			return err
+		}
+
+		return checkAmount(operation.Args[1])
+	case OpPay:
+		err := validateAccount(operation.Args[0])
+		if err != nil {
+			return err
+		}
+
+		err = checkAmount(operation.Args[1])
+		if err != nil {
+			return err
+		}
+
+		if operation.Args[2] == "" {
+			return errors.New("category is required")
+		}
+	case OpTransfer:
+		for _, ref := range operation.Args[:2] {
+			err := validateAccount(ref)
+			if err != nil {
+				return err
+			}
+		}
+
+		return checkAmount(operation.Args[2])
+	case OpReject, OpFavorite:
+		err := validatePayment(operation.Args[0], labels)
+		if err != nil {
+			return err
+		}
+
+		if operation.Name == OpFavorite && operation.Args[1] == "" {
+			return errors.New("favorite name is required")
+		}
+	}
+
+	return nil
+}
+
+func validateAccount(ref string) error {
+	if strings.HasPrefix(ref, "+") {
+		return nil
+	}
+
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid account %q, use id or phone", ref)
+	}
+
+	return nil
+}
+
+func validatePayment(ref string, labels map[string]bool) error {
+	if ref == "" {
+		return errors.New("payment is required")
+	}
+
+	if strings.HasPrefix(ref, "@") {
+		if _, ok := labels[ref[1:]]; !ok {
+			return fmt.Errorf("label %s is not defined above", ref)
+		}
+	}
+
+	return nil
+}
+
+// checkAmount validates amount before the currency of the account is known
+func checkAmount(value string) error {
+	amount, err := money.ParseDecimal(value, money.MaxMinor)
+	if err != nil || amount <= 0 {
+		return fmt.Errorf("invalid amount %q, must be positive decimal like 12.50", value)
+	}
+
+	return nil
+}
+
+// parseAmount reads amount in minor units of the currency
+func parseAmount(value string, currency types.Currency) (types.Money, error) {
+	amount, err := money.Parse(value, currency)
+	if err != nil {
+		return 0, err
+	}
+
+	if amount <= 0 {
+		return 0, fmt.Errorf("invalid amount %q, must be positive", value)
+	}
+
+	return amount, nil
+}
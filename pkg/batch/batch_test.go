@@ -0,0 +1,310 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+const testBatch = `# accounts
+register,+992937452945
+deposit,+992937452945,100.00
+pay,1,25,auto,@p1
+favorite,@p1,car
+reject,@p1
+`
+
+func TestParse_success(t *testing.T) {
+	operations, err := Parse(strings.NewReader(testBatch))
+	if err != nil {
+		t.Errorf("Parse(): error = %v", err)
+		return
+	}
+
+	if len(operations) != 5 {
+		t.Errorf("Parse(): wrong number of operations = %v", len(operations))
+		return
+	}
+
+	pay := operations[2]
+	if pay.Line != 4 || pay.Name != OpPay || pay.Label != "p1" || len(pay.Args) != 3 {
+		t.Errorf("Parse(): wrong operation = %v", pay)
+		return
+	}
+}
+
+func TestParse_fail(t *testing.T) {
+	data := `register
+deposit,abc,100
+pay,1,-5,auto
+reject,@unknown
+transfer,1,2
+pay,1,100,auto,@p1
+pay,1,100,auto,@p1
+favorite,@p1,
+deposit,1,1.0005
+`
+	_, err := Parse(strings.NewReader(data))
+	if !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("Parse(): must return ErrInvalidBatch, but error = %v", err)
+		return
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("Parse(): must return ValidationError, but error = %v", err)
+		return
+	}
+
+	lines := []int{ 1, 2, 3, 4, 5, 7, 8, 9 }
+	if len(verr.Errors) != len(lines) {
+		t.Errorf("Parse(): all invalid lines must be reported, errors = %v", verr)
+		return
+	}
+
+	for i, line := range lines {
+		if verr.Errors[i].Line != line {
+			t.Errorf("Parse(): wrong line reported = %v, want %v", verr.Errors[i].Line, line)
+		}
+	}
+}
+
+func TestRunner_Run_success(t *testing.T) {
+	svc := &wallet.Service{}
+	operations, err := Parse(strings.NewReader(testBatch))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report, err := NewRunner(svc, AllOrNothing).Run(operations)
+	if err != nil {
+		t.Errorf("Run(): error = %v", err)
+		return
+	}
+
+	if report.Failed() != 0 || len(report.Results) != 5 {
+		t.Errorf("Run(): wrong report = %v", report.Results)
+		return
+	}
+
+	account, err := svc.FindAccountByID(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Balance != 10000 {
+		t.Errorf("Run(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	payment, err := svc.FindPaymentByID(report.Results[2].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusFail {
+		t.Errorf("Run(): labeled payment must be rejected, payment = %v", payment)
+		return
+	}
+}
+
+func TestRunner_Run_allOrNothing(t *testing.T) {
+	svc := &wallet.Service{}
+	operations, err := Parse(strings.NewReader(`register,+992937452945
+deposit,1,100
+pay,1,500,auto
+deposit,1,100
+`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report, err := NewRunner(svc, AllOrNothing).Run(operations)
+	if err != ErrBatchFailed {
+		t.Errorf("Run(): must return ErrBatchFailed, but error = %v", err)
+		return
+	}
+
+	statuses := []string{ StatusRolledBack, StatusRolledBack, StatusFailed, StatusSkipped }
+	for i, status := range statuses {
+		if report.Results[i].Status != status {
+			t.Errorf("Run(): wrong status of line %v = %v, want %v", i + 1, report.Results[i].Status, status)
+		}
+	}
+
+	if !errors.Is(report.Results[2].Err, wallet.ErrNotEnoughBalance) {
+		t.Errorf("Run(): must report ErrNotEnoughBalance, but error = %v", report.Results[2].Err)
+		return
+	}
+
+	if len(svc.GetAccounts()) != 0 {
+		t.Errorf("Run(): batch must be rolled back, accounts = %v", len(svc.GetAccounts()))
+		return
+	}
+}
+
+func TestRunner_Run_continueOnError(t *testing.T) {
+	svc := &wallet.Service{}
+	operations, err := Parse(strings.NewReader(`register,+992937452945
+pay,1,500,auto,@p1
+reject,@p1
+deposit,+992937452945,100
+`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report, err := NewRunner(svc, ContinueOnError).Run(operations)
+	if err != ErrBatchFailed {
+		t.Errorf("Run(): must return ErrBatchFailed, but error = %v", err)
+		return
+	}
+
+	if report.Failed() != 2 {
+		t.Errorf("Run(): wrong number of failures = %v", report.Failed())
+		return
+	}
+
+	if report.Results[2].Err != ErrLabelNotResolved {
+		t.Errorf("Run(): must return ErrLabelNotResolved, but error = %v", report.Results[2].Err)
+		return
+	}
+
+	account, err := svc.FindAccountByID(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Balance != 100_00 {
+		t.Errorf("Run(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = report.WriteCSV(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || lines[0] != "line,operation,status,id,fee,error" {
+		t.Errorf("WriteCSV(): wrong report = %s", buf)
+		return
+	}
+
+	if !strings.HasPrefix(lines[2], "2,pay,failed,,,") {
+		t.Errorf("WriteCSV(): wrong line = %s", lines[2])
+		return
+	}
+
+	if lines[4] != "4,deposit,ok,1,0.00 TJS," {
+		t.Errorf("WriteCSV(): fee must be formatted, line = %s", lines[4])
+		return
+	}
+}
+
+func TestRunner_Run_currencyDigits(t *testing.T) {
+	svc := &wallet.Service{}
+	operations, err := Parse(strings.NewReader(`register,+992937452945
+deposit,1,10.505
+deposit,1,10.50
+`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report, err := NewRunner(svc, ContinueOnError).Run(operations)
+	if err != ErrBatchFailed {
+		t.Errorf("Run(): must return ErrBatchFailed, but error = %v", err)
+		return
+	}
+
+	if !errors.Is(report.Results[1].Err, money.ErrInvalidAmount) {
+		t.Errorf("Run(): TJS amount with 3 digits must fail, error = %v", report.Results[1].Err)
+		return
+	}
+
+	account, err := svc.FindAccountByID(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Balance != 10_50 {
+		t.Errorf("Run(): wrong balance = %v", account.Balance)
+		return
+	}
+}
+
+func TestRunner_Run_fees(t *testing.T) {
+	schedule, err := fees.Load(strings.NewReader(`fee;TJS;auto;0;0;0.50;0;0;0
+fee;TJS;transfer;0;0;0.25;0;0;0
+`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	svc, err := wallet.NewService(wallet.WithFees(schedule))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	operations, err := Parse(strings.NewReader(`register,+992937452945
+register,+992937452946
+deposit,1,100
+pay,1,10,auto,@p1
+transfer,1,+992937452946,20,@t1
+reject,@p1
+`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report, err := NewRunner(svc, AllOrNothing).Run(operations)
+	if err != nil {
+		t.Errorf("Run(): error = %v", err)
+		return
+	}
+
+	want := []types.Money{ 0, 0, 0, 50, 25, 0 }
+	for i, fee := range want {
+		if report.Results[i].Fee != fee {
+			t.Errorf("Run(): wrong fee of line %v = %v, want %v", i + 1, report.Results[i].Fee, fee)
+		}
+	}
+
+	target, err := svc.FindAccountByPhone("+992937452946")
+	if err != nil || target.Balance != 20_00 {
+		t.Errorf("Run(): transfer must credit the target, account = %v, error = %v", target, err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = report.WriteCSV(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[5] != "5,transfer,ok," + report.Results[4].ID + ",0.25 TJS," {
+		t.Errorf("WriteCSV(): wrong line = %s", lines[5])
+		return
+	}
+}
@@ -0,0 +1,330 @@
+package batch
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// Mode - how the runner reacts to a failed operation
+type Mode int
+
+// Supported modes
+const (
+	// AllOrNothing stops at the first failure and rolls back the whole batch
+	AllOrNothing Mode = iota
+	// ContinueOnError executes every operation and reports failures per line
+	ContinueOnError
+)
+
+// Result statuses
+const (
+	StatusOk			= "ok"
+	StatusFailed		= "failed"
+	StatusRolledBack	= "rolled back"
+	StatusSkipped		= "skipped"
+)
+
+// ErrBatchFailed - at least one operation of the batch failed
+var ErrBatchFailed = errors.New("Batch operation failed")
+
+// ErrLabelNotResolved - payment of the label was not created
+var ErrLabelNotResolved = errors.New("Labeled payment was not created")
+
+// Result - outcome of a single operation
+type Result struct {
+	Line		int
+	Operation	string
+	Status		string
+	ID			string
+	Currency	types.Currency	// currency of the fee, empty if the operation failed
+	Fee			types.Money		// fee charged on top of the payment
+	Err			error
+}
+
+// Report - outcome of the whole batch
+type Report struct {
+	Results	[]Result
+}
+
+// Runner executes batch operations against the service
+type Runner struct {
+	svc		*wallet.Service
+	mode	Mode
+}
+
+// NewRunner creates runner for the service
+func NewRunner(svc *wallet.Service, mode Mode) *Runner {
+	return &Runner {
+		svc:	svc,
+		mode:	mode,
+	}
+}
+
+// Run executes operations, the report is returned even if the batch failed
+func (r *Runner) Run(operations []Operation) (*Report, error) {
+	err := Validate(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	snapshot := r.svc.Snapshot()
+	labels := make(map[string]string)
+	failed := false
+
+	for i, operation := range operations {
+		id, currency, err := r.execute(operation, labels)
+		result := Result {
+			Line:		operation.Line,
+			Operation:	operation.Name,
+			Status:		StatusOk,
+			ID:			id,
+			Currency:	currency,
+			Err:		err,
+		}
+
+		if err != nil {
+			result.Status = StatusFailed
+			failed = true
+		} else {
+			result.Fee = r.fee(operation.Name, id)
+		}
+
+		if operation.Label != "" && err == nil {
+			labels[operation.Label] = id
+		}
+
+		report.Results = append(report.Results, result)
+
+		if err != nil && r.mode == AllOrNothing {
+			r.svc.Restore(snapshot)
+			report.rollback(operations[i + 1:])
+			return report, ErrBatchFailed
+		}
+	}
+
+	if failed {
+		return report, ErrBatchFailed
+	}
+
+	return report, nil
+}
+
+// Failed returns number of failed operations
+func (r *Report) Failed() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			count++
+		}
+	}
+
+	return count
+}
+
+// WriteCSV exports report as CSV with a header line, fees are written by money.Plain
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{ "line", "operation", "status", "id", "fee", "error" })
+	if err != nil {
+		return err
+	}
+
+	for _, result := range r.Results {
+		message := ""
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+
+		fee := ""
+		if result.Currency != "" {
+			fee = money.Format(result.Fee, result.Currency, money.Plain)
+		}
+
+		err = writer.Write([]string {
+			strconv.Itoa(result.Line),
+			result.Operation,
+			result.Status,
+			result.ID,
+			fee,
+			message,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// rollback marks executed results as rolled back and the rest as skipped
+func (r *Report) rollback(rest []Operation) {
+	for i := range r.Results {
+		if r.Results[i].Status == StatusOk {
+			r.Results[i].Status = StatusRolledBack
+			r.Results[i].ID = ""
+			r.Results[i].Fee = 0
+			r.Results[i].Currency = ""
+		}
+	}
+
+	for _, operation := range rest {
+		r.Results = append(r.Results, Result {
+			Line:		operation.Line,
+			Operation:	operation.Name,
+			Status:		StatusSkipped,
+		})
+	}
+}
+
+// execute runs operation and returns id of the created or changed object with currency of its amounts
+func (r *Runner) execute(operation Operation, labels map[string]string) (string, types.Currency, error) {
+	args := operation.Args
+
+	switch operation.Name {
+	case OpRegister:
+		account, err := r.svc.RegisterAccount(types.Phone(args[0]))
+		if err != nil {
+			return "", "", err
+		}
+
+		return strconv.FormatInt(account.ID, 10), wallet.AccountCurrency(account), nil
+	case OpDeposit:
+		account, err := r.findAccount(args[0])
+		if err != nil {
+			return "", "", err
+		}
+
+		amount, err := parseAmount(args[1], wallet.AccountCurrency(account))
+		if err != nil {
+			return "", "", err
+		}
+
+		err = r.svc.Deposit(account.ID, amount)
+		if err != nil {
+			return "", "", err
+		}
+
+		return strconv.FormatInt(account.ID, 10), wallet.AccountCurrency(account), nil
+	case OpPay:
+		account, err := r.findAccount(args[0])
+		if err != nil {
+			return "", "", err
+		}
+
+		amount, err := parseAmount(args[1], wallet.AccountCurrency(account))
+		if err != nil {
+			return "", "", err
+		}
+
+		payment, err := r.svc.Pay(account.ID, amount, types.PaymentCategory(args[2]))
+		if err != nil {
+			return "", "", err
+		}
+
+		return payment.ID, wallet.AccountCurrency(account), nil
+	case OpTransfer:
+		from, err := r.findAccount(args[0])
+		if err != nil {
+			return "", "", err
+		}
+
+		to, err := r.findAccount(args[1])
+		if err != nil {
+			return "", "", err
+		}
+
+		amount, err := parseAmount(args[2], wallet.AccountCurrency(from))
+		if err != nil {
+			return "", "", err
+		}
+
+		payment, err := r.svc.Transfer(from.ID, to.ID, amount)
+		if err != nil {
+			return "", "", err
+		}
+
+		return payment.ID, wallet.AccountCurrency(from), nil
+	case OpReject:
+		paymentID, err := resolvePayment(args[0], labels)
+		if err != nil {
+			return "", "", err
+		}
+
+		err = r.svc.Reject(paymentID)
+		if err != nil {
+			return "", "", err
+		}
+
+		payment, err := r.svc.FindPaymentByID(paymentID)
+		if err != nil {
+			return "", "", err
+		}
+
+		return paymentID, wallet.PaymentCurrency(payment), nil
+	case OpFavorite:
+		paymentID, err := resolvePayment(args[0], labels)
+		if err != nil {
+			return "", "", err
+		}
+
+		favorite, err := r.svc.FavoritePayment(paymentID, args[1])
+		if err != nil {
+			return "", "", err
+		}
+
+		account, err := r.svc.FindAccountByID(favorite.AccountID)
+		if err != nil {
+			return "", "", err
+		}
+
+		return favorite.ID, wallet.AccountCurrency(account), nil
+	}
+
+	return "", "", nil
+}
+
+// fee returns fee charged by the operation, only payments and transfers are charged
+// and the fee of a rejected payment is returned with it
+func (r *Runner) fee(name string, id string) types.Money {
+	if name != OpPay && name != OpTransfer {
+		return 0
+	}
+
+	fee, err := r.svc.FindFee(id)
+	if err != nil {
+		return 0
+	}
+
+	return fee.Amount
+}
+
+func (r *Runner) findAccount(ref string) (*types.Account, error) {
+	if strings.HasPrefix(ref, "+") {
+		return r.svc.FindAccountByPhone(types.Phone(ref))
+	}
+
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	return r.svc.FindAccountByID(id)
+}
+
+func resolvePayment(ref string, labels map[string]string) (string, error) {
+	if !strings.HasPrefix(ref, "@") {
+		return ref, nil
+	}
+
+	id, ok := labels[ref[1:]]
+	if !ok {
+		return "", ErrLabelNotResolved
+	}
+
+	return id, nil
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/google/uuid"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/api"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// ErrUnexpectedResponse - server returned a response which can't be decoded
+var ErrUnexpectedResponse = errors.New("Unexpected response from wallet API")
+
+// APIError - error returned by the wallet API, unwraps to the service error if known
+type APIError struct {
+	Status	int
+	Code	string
+	Message	string
+	err		error
+}
+
+// Error returns message of the API error
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns service error such as wallet.ErrNotEnoughBalance
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// Client - typed client for the wallet HTTP API
+type Client struct {
+	BaseURL		string
+	HTTPClient	*http.Client
+	MaxRetries	int
+	Backoff		time.Duration
+}
+
+// New creates client for the API at the given base URL
+func New(baseURL string) *Client {
+	return &Client {
+		BaseURL:	strings.TrimRight(baseURL, "/"),
+		HTTPClient:	&http.Client{ Timeout: 10 * time.Second },
+		MaxRetries:	3,
+		Backoff:	100 * time.Millisecond,
+	}
+}
+
+// RegisterAccount registering new account
+func (c *Client) RegisterAccount(phone types.Phone) (*types.Account, error) {
+	account := &types.Account{}
+	err := c.do(http.MethodPost, "/accounts", api.RegisterAccountRequest{ Phone: phone }, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Deposit add money based on account id
+func (c *Client) Deposit(accountID int64, amount types.Money) error {
+	path := "/accounts/" + strconv.FormatInt(accountID, 10) + "/deposit"
+	return c.do(http.MethodPost, path, api.DepositRequest{ Amount: amount }, nil)
+}
+
+// Pay is a payment operation
+func (c *Client) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	req := api.PayRequest {
+		AccountID:	accountID,
+		Amount:		amount,
+		Category:	category,
+	}
+
+	payment := &types.Payment{}
+	err := c.do(http.MethodPost, "/payments", req, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// QuotePayment previews fee and total of the payment
+func (c *Client) QuotePayment(accountID int64, amount types.Money, category types.PaymentCategory) (*wallet.FeeQuote, error) {
+	req := api.PayRequest {
+		AccountID:	accountID,
+		Amount:		amount,
+		Category:	category,
+	}
+
+	quote := &wallet.FeeQuote{}
+	err := c.do(http.MethodPost, "/payments/quote", req, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// Reject cencel payment
+func (c *Client) Reject(paymentID string) error {
+	return c.do(http.MethodPost, "/payments/" + escape(paymentID) + "/reject", nil, nil)
+}
+
+// Repeat payment
+func (c *Client) Repeat(paymentID string) (*types.Payment, error) {
+	payment := &types.Payment{}
+	err := c.do(http.MethodPost, "/payments/" + escape(paymentID) + "/repeat", nil, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// FavoritePayment creates favorite payment
+func (c *Client) FavoritePayment(paymentID string, name string) (*types.Favorite, error) {
+	req := api.FavoriteRequest {
+		PaymentID:	paymentID,
+		Name:		name,
+	}
+
+	favorite := &types.Favorite{}
+	err := c.do(http.MethodPost, "/favorites", req, favorite)
+	if err != nil {
+		return nil, err
+	}
+
+	return favorite, nil
+}
+
+// PayFromFavorite pay from favorite payment
+func (c *Client) PayFromFavorite(favoriteID string) (*types.Payment, error) {
+	payment := &types.Payment{}
+	err := c.do(http.MethodPost, "/favorites/" + escape(favoriteID) + "/pay", nil, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// FindAccountByID find account by id
+func (c *Client) FindAccountByID(accountID int64) (*types.Account, error) {
+	account := &types.Account{}
+	err := c.do(http.MethodGet, "/accounts/" + strconv.FormatInt(accountID, 10), nil, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// FindPaymentByID searching payment by id
+func (c *Client) FindPaymentByID(paymentID string) (*types.Payment, error) {
+	payment := &types.Payment{}
+	err := c.do(http.MethodGet, "/payments/" + escape(paymentID), nil, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// FindFavoriteByID searching favorite by id
+func (c *Client) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
+	favorite := &types.Favorite{}
+	err := c.do(http.MethodGet, "/favorites/" + escape(favoriteID), nil, favorite)
+	if err != nil {
+		return nil, err
+	}
+
+	return favorite, nil
+}
+
+// ExportAccountHistory get payments by accountid
+func (c *Client) ExportAccountHistory(accountID int64) ([]*types.Payment, error) {
+	var payments []*types.Payment
+	err := c.do(http.MethodGet, "/accounts/" + strconv.FormatInt(accountID, 10) + "/payments", nil, &payments)
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// Export asks server to export all data into its export directory
+func (c *Client) Export() error {
+	return c.do(http.MethodPost, "/export", nil, nil)
+}
+
+// do sends request and retries it on network errors and 5xx responses,
+// POST requests carry the same idempotency key in every attempt
+func (c *Client) do(method string, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	key := ""
+	if method == http.MethodPost {
+		key = uuid.New().String()
+	}
+
+	var err error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.Backoff * time.Duration(1 << uint(attempt - 1)))
+		}
+
+		var retry bool
+		retry, err = c.send(method, path, payload, key, out)
+		if !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+// send makes single attempt and reports whether the request may be retried
+func (c *Client) send(method string, path string, payload []byte, key string, out interface{}) (bool, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL + path, body)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if key != "" {
+		req.Header.Set(api.IdempotencyKeyHeader, key)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if resp.StatusCode >= 400 {
+		apiErr := decodeError(resp.StatusCode, data)
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrUnexpectedResponse, err)
+	}
+
+	return false, nil
+}
+
+func decodeError(status int, data []byte) error {
+	var body api.ErrorBody
+	err := json.Unmarshal(data, &body)
+	if err != nil || body.Error.Code == "" {
+		return &APIError {
+			Status:		status,
+			Message:	http.StatusText(status),
+			err:		ErrUnexpectedResponse,
+		}
+	}
+
+	return &APIError {
+		Status:		status,
+		Code:		body.Error.Code,
+		Message:	body.Error.Message,
+		err:		api.ErrorOf(body.Error.Code),
+	}
+}
+
+func escape(id string) string {
+	return url.PathEscape(id)
+}
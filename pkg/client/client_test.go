@@ -0,0 +1,186 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/server"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+func TestClient_Pay_success(t *testing.T) {
+	api := httptest.NewServer(server.New(&wallet.Service{}, "", wallet.NopLogger{}))
+	defer api.Close()
+
+	c := New(api.URL)
+
+	account, err := c.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	err = c.Deposit(account.ID, 100)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	payment, err := c.Pay(account.ID, 40, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	favorite, err := c.FavoritePayment(payment.ID, "auto")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	_, err = c.PayFromFavorite(favorite.ID)
+	if err != nil {
+		t.Errorf("PayFromFavorite(): error = %v", err)
+		return
+	}
+
+	err = c.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	_, err = c.Repeat(payment.ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+
+	account, err = c.FindAccountByID(account.ID)
+	if err != nil {
+		t.Errorf("FindAccountByID(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 20 {
+		t.Errorf("FindAccountByID(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	payments, err := c.ExportAccountHistory(account.ID)
+	if err != nil {
+		t.Errorf("ExportAccountHistory(): error = %v", err)
+		return
+	}
+
+	if len(payments) != 3 {
+		t.Errorf("ExportAccountHistory(): wrong size of history = %v", len(payments))
+		return
+	}
+}
+
+func TestClient_Errors_sentinel(t *testing.T) {
+	api := httptest.NewServer(server.New(&wallet.Service{}, "", wallet.NopLogger{}))
+	defer api.Close()
+
+	c := New(api.URL)
+
+	_, err := c.FindAccountByID(1)
+	if !errors.Is(err, wallet.ErrAccountNotFound) {
+		t.Errorf("FindAccountByID(): must return ErrAccountNotFound, but error = %v", err)
+		return
+	}
+
+	account, err := c.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = c.RegisterAccount("+992937452945")
+	if !errors.Is(err, wallet.ErrPhoneRegistered) {
+		t.Errorf("RegisterAccount(): must return ErrPhoneRegistered, but error = %v", err)
+		return
+	}
+
+	_, err = c.Pay(account.ID, 100, "auto")
+	if !errors.Is(err, wallet.ErrNotEnoughBalance) {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, but error = %v", err)
+		return
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity {
+		t.Errorf("Pay(): must return APIError with status 422, but error = %v", err)
+		return
+	}
+}
+
+func TestClient_Retry_idempotent(t *testing.T) {
+	srv := server.New(&wallet.Service{}, "", wallet.NopLogger{})
+
+	// first attempt of every request reaches the service, but the response is lost
+	var calls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) % 2 == 1 {
+			srv.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		srv.ServeHTTP(w, r)
+	}))
+	defer api.Close()
+
+	c := New(api.URL)
+	c.Backoff = time.Millisecond
+
+	account, err := c.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	err = c.Deposit(account.ID, 100)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	account, err = c.FindAccountByID(account.ID)
+	if err != nil {
+		t.Errorf("FindAccountByID(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 100 {
+		t.Errorf("Deposit(): retry must not deposit twice, balance = %v", account.Balance)
+		return
+	}
+}
+
+func TestClient_Retry_exhausted(t *testing.T) {
+	var calls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer api.Close()
+
+	c := New(api.URL)
+	c.Backoff = time.Millisecond
+
+	_, err := c.FindAccountByID(1)
+	if !errors.Is(err, ErrUnexpectedResponse) {
+		t.Errorf("FindAccountByID(): must return ErrUnexpectedResponse, but error = %v", err)
+		return
+	}
+
+	if calls != int32(c.MaxRetries + 1) {
+		t.Errorf("FindAccountByID(): wrong number of attempts = %v", calls)
+		return
+	}
+}
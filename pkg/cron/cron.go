@@ -0,0 +1,313 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec - schedule spec can't be parsed
+var ErrInvalidSpec = errors.New("Invalid schedule spec")
+
+// Schedule - times at which something repeats
+type Schedule interface {
+	// Next returns the first time of the schedule after the given one
+	Next(after time.Time) time.Time
+}
+
+// Parse parses one of the specs:
+//	daily 09:30
+//	weekly mon 09:30
+//	monthly 31 09:30	- days missing in the month fall on its last day
+//	30 9 * * 1-5		- cron expression: minute hour day-of-month month day-of-week
+// times are taken in the location of the time passed to Next
+func Parse(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: spec is empty", ErrInvalidSpec)
+	}
+
+	switch fields[0] {
+	case "daily":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: want daily HH:MM, got %q", ErrInvalidSpec, spec)
+		}
+
+		hour, minute, err := parseClock(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return &daily{ hour: hour, minute: minute }, nil
+
+	case "weekly":
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%w: want weekly DAY HH:MM, got %q", ErrInvalidSpec, spec)
+		}
+
+		weekday, ok := weekdays[strings.ToLower(fields[1])]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown weekday %q", ErrInvalidSpec, fields[1])
+		}
+
+		hour, minute, err := parseClock(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return &weekly{ weekday: weekday, hour: hour, minute: minute }, nil
+
+	case "monthly":
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%w: want monthly DAY HH:MM, got %q", ErrInvalidSpec, spec)
+		}
+
+		day, err := strconv.Atoi(fields[1])
+		if err != nil || day < 1 || day > 31 {
+			return nil, fmt.Errorf("%w: day of month %q must be 1-31", ErrInvalidSpec, fields[1])
+		}
+
+		hour, minute, err := parseClock(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return &monthly{ day: day, hour: hour, minute: minute }, nil
+	}
+
+	return parseExpression(fields)
+}
+
+var weekdays = map[string]time.Weekday {
+	"sun":	time.Sunday,
+	"mon":	time.Monday,
+	"tue":	time.Tuesday,
+	"wed":	time.Wednesday,
+	"thu":	time.Thursday,
+	"fri":	time.Friday,
+	"sat":	time.Saturday,
+}
+
+func parseClock(value string) (int, int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidSpec, value)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("%w: hour %q must be 0-23", ErrInvalidSpec, parts[0])
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("%w: minute %q must be 0-59", ErrInvalidSpec, parts[1])
+	}
+
+	return hour, minute, nil
+}
+
+type daily struct {
+	hour	int
+	minute	int
+}
+
+// Next returns the next day at the time
+func (d *daily) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+type weekly struct {
+	weekday	time.Weekday
+	hour	int
+	minute	int
+}
+
+// Next returns the next weekday at the time
+func (w *weekly) Next(after time.Time) time.Time {
+	days := (int(w.weekday) - int(after.Weekday()) + 7) % 7
+	next := time.Date(after.Year(), after.Month(), after.Day() + days, w.hour, w.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 7)
+	}
+
+	return next
+}
+
+type monthly struct {
+	day		int
+	hour	int
+	minute	int
+}
+
+// Next returns the day of the next month at the time
+func (m *monthly) Next(after time.Time) time.Time {
+	next := m.in(after.Year(), after.Month(), after.Location())
+	if !next.After(after) {
+		next = m.in(after.Year(), after.Month() + 1, after.Location())
+	}
+
+	return next
+}
+
+func (m *monthly) in(year int, month time.Month, location *time.Location) time.Time {
+	// day 0 of the next month is the last day of this one
+	last := time.Date(year, month + 1, 0, 0, 0, 0, 0, location).Day()
+
+	day := m.day
+	if day > last {
+		day = last
+	}
+
+	return time.Date(year, month, day, m.hour, m.minute, 0, 0, location)
+}
+
+type expression struct {
+	minutes		[]bool
+	hours		[]bool
+	days		[]bool
+	months		[]bool
+	weekdays	[]bool
+	anyDay		bool
+	anyWeekday	bool
+}
+
+func parseExpression(fields []string) (Schedule, error) {
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: cron expression must have 5 fields, got %d", ErrInvalidSpec, len(fields))
+	}
+
+	e := &expression {
+		anyDay:		fields[2] == "*",
+		anyWeekday:	fields[4] == "*",
+	}
+
+	ranges := []struct {
+		set		*[]bool
+		min		int
+		max		int
+	} {
+		{ set: &e.minutes,	min: 0,	max: 59 },
+		{ set: &e.hours,	min: 0,	max: 23 },
+		{ set: &e.days,		min: 1,	max: 31 },
+		{ set: &e.months,	min: 1,	max: 12 },
+		{ set: &e.weekdays,	min: 0,	max: 7 },
+	}
+
+	for i, r := range ranges {
+		set, err := parseField(fields[i], r.min, r.max)
+		if err != nil {
+			return nil, err
+		}
+		*r.set = set
+	}
+
+	// both 0 and 7 mean Sunday
+	e.weekdays[0] = e.weekdays[0] || e.weekdays[7]
+
+	return e, nil
+}
+
+// parseField parses comma separated list of *, N, N-M with optional /STEP
+func parseField(field string, min int, max int) ([]bool, error) {
+	set := make([]bool, max + 1)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			value, err := strconv.Atoi(part[i + 1:])
+			if err != nil || value < 1 {
+				return nil, fmt.Errorf("%w: bad step in %q", ErrInvalidSpec, field)
+			}
+			step = value
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			value, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad value in %q", ErrInvalidSpec, field)
+			}
+			from, to = value, value
+
+			if len(bounds) == 2 {
+				to, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("%w: bad range in %q", ErrInvalidSpec, field)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%w: %q is out of range %d-%d", ErrInvalidSpec, field, min, max)
+		}
+
+		for value := from; value <= to; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the next minute matching the expression, zero time if there is none in 5 years
+func (e *expression) Next(after time.Time) time.Time {
+	location := after.Location()
+	next := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute() + 1, 0, 0, location)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if !e.months[next.Month()] {
+			next = time.Date(next.Year(), next.Month() + 1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if !e.matchDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day() + 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if !e.hours[next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour() + 1, 0, 0, 0, location)
+			continue
+		}
+
+		if !e.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+// matchDay follows cron rule: when both day fields are restricted either of them may match
+func (e *expression) matchDay(t time.Time) bool {
+	day := e.days[t.Day()]
+	weekday := e.weekdays[t.Weekday()]
+
+	switch {
+	case e.anyDay && e.anyWeekday:
+		return true
+	case e.anyDay:
+		return weekday
+	case e.anyWeekday:
+		return day
+	}
+
+	return day || weekday
+}
@@ -0,0 +1,87 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse_next(t *testing.T) {
+	// Wednesday
+	after := time.Date(2021, 1, 27, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec	string
+		want	time.Time
+	} {
+		{ spec: "daily 09:30",		want: time.Date(2021, 1, 28, 9, 30, 0, 0, time.UTC) },
+		{ spec: "daily 10:01",		want: time.Date(2021, 1, 27, 10, 1, 0, 0, time.UTC) },
+		{ spec: "daily 10:00",		want: time.Date(2021, 1, 28, 10, 0, 0, 0, time.UTC) },
+		{ spec: "weekly mon 09:00",	want: time.Date(2021, 2, 1, 9, 0, 0, 0, time.UTC) },
+		{ spec: "weekly wed 11:00",	want: time.Date(2021, 1, 27, 11, 0, 0, 0, time.UTC) },
+		{ spec: "weekly wed 09:00",	want: time.Date(2021, 2, 3, 9, 0, 0, 0, time.UTC) },
+		{ spec: "monthly 31 08:00",	want: time.Date(2021, 1, 31, 8, 0, 0, 0, time.UTC) },
+		{ spec: "monthly 15 08:00",	want: time.Date(2021, 2, 15, 8, 0, 0, 0, time.UTC) },
+		{ spec: "*/15 * * * *",		want: time.Date(2021, 1, 27, 10, 15, 0, 0, time.UTC) },
+		{ spec: "0 9 * * 1-5",		want: time.Date(2021, 1, 28, 9, 0, 0, 0, time.UTC) },
+		{ spec: "0 0 1 3 *",		want: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC) },
+		{ spec: "0 12 13 * 5",		want: time.Date(2021, 1, 29, 12, 0, 0, 0, time.UTC) },
+		{ spec: "0 0 * * 7",		want: time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC) },
+	}
+
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): error = %v", test.spec, err)
+			continue
+		}
+
+		got := schedule.Next(after)
+		if !got.Equal(test.want) {
+			t.Errorf("Next(%q): got %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestParse_monthlyShortMonth(t *testing.T) {
+	schedule, err := Parse("monthly 31 08:00")
+	if err != nil {
+		t.Errorf("Parse(): error = %v", err)
+		return
+	}
+
+	next := schedule.Next(time.Date(2021, 1, 31, 8, 0, 0, 0, time.UTC))
+	if !next.Equal(time.Date(2021, 2, 28, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next(): must fall on the last day of February, got %v", next)
+		return
+	}
+
+	next = schedule.Next(next)
+	if !next.Equal(time.Date(2021, 3, 31, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next(): must return to the 31st, got %v", next)
+		return
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	specs := []string {
+		"",
+		"daily",
+		"daily 24:00",
+		"daily 9",
+		"weekly funday 09:00",
+		"monthly 32 09:00",
+		"* * * *",
+		"60 * * * *",
+		"* * * 13 *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+	}
+
+	for _, spec := range specs {
+		_, err := Parse(spec)
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("Parse(%q): must return ErrInvalidSpec, but error = %v", spec, err)
+		}
+	}
+}
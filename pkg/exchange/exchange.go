@@ -0,0 +1,173 @@
+package exchange
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// RateScale - rates are integers in millionths of the quote currency per unit of the base one
+const RateScale = 1_000_000
+
+// ratePlaces - decimals of rates, RateScale is 10^ratePlaces
+const ratePlaces = 6
+
+// ErrRateNotFound - table has no rate between the currencies
+var ErrRateNotFound = errors.New("Exchange rate not found")
+
+// ErrInvalidTable - rates are malformed
+var ErrInvalidTable = errors.New("Invalid exchange rate table")
+
+// ErrAmountTooLarge - converted amount doesn't fit into types.Money
+var ErrAmountTooLarge = errors.New("Converted amount is too large")
+
+// Rate - price of one unit of Base in Quote, Bid is paid to customers selling Base
+// and Ask is charged to customers buying it, the difference is the spread
+type Rate struct {
+	Base	types.Currency
+	Quote	types.Currency
+	Bid		int64	// millionths of Quote
+	Ask		int64	// millionths of Quote
+}
+
+// Table - rates between pairs of currencies, a pair is listed in one direction only
+type Table struct {
+	rates	map[string]Rate
+}
+
+// NewTable checks rates and creates table of them
+func NewTable(rates []Rate) (*Table, error) {
+	table := &Table{ rates: make(map[string]Rate, len(rates)) }
+	for _, rate := range rates {
+		_, baseErr := money.Lookup(rate.Base)
+		_, quoteErr := money.Lookup(rate.Quote)
+		if baseErr != nil || quoteErr != nil || rate.Base == rate.Quote {
+			return nil, fmt.Errorf("%w: bad pair %s/%s", ErrInvalidTable, rate.Base, rate.Quote)
+		}
+
+		if rate.Bid <= 0 || rate.Bid > rate.Ask {
+			return nil, fmt.Errorf("%w: bid of %s/%s must be positive and not above ask", ErrInvalidTable, rate.Base, rate.Quote)
+		}
+
+		_, direct := table.rates[pair(rate.Base, rate.Quote)]
+		_, inverse := table.rates[pair(rate.Quote, rate.Base)]
+		if direct || inverse {
+			return nil, fmt.Errorf("%w: pair %s/%s is repeated", ErrInvalidTable, rate.Base, rate.Quote)
+		}
+
+		table.rates[pair(rate.Base, rate.Quote)] = rate
+	}
+
+	return table, nil
+}
+
+// Load reads table from lines like:
+//	# comment
+//	rate;USD;TJS;10.90;11.05	- base, quote, bid and ask with up to 6 decimals
+func Load(reader io.Reader) (*Table, error) {
+	var rates []Rate
+
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		if fields[0] != "rate" || len(fields) != 5 {
+			return nil, fmt.Errorf("%w: line %d: unknown record %q", ErrInvalidTable, line, text)
+		}
+
+		bid, bidErr := ParseRate(fields[3])
+		ask, askErr := ParseRate(fields[4])
+		if bidErr != nil || askErr != nil {
+			return nil, fmt.Errorf("%w: line %d: bad rate", ErrInvalidTable, line)
+		}
+
+		rates = append(rates, Rate{ Base: types.Currency(fields[1]), Quote: types.Currency(fields[2]), Bid: bid, Ask: ask })
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTable(rates)
+}
+
+// ParseRate parses decimal rate like 10.9 into millionths
+func ParseRate(value string) (int64, error) {
+	rate, err := money.ParseDecimal(value, ratePlaces)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("%w: rate %q", ErrInvalidTable, value)
+	}
+
+	return rate, nil
+}
+
+// FormatRate formats millionths as decimal rate without trailing zeros
+func FormatRate(rate int64) string {
+	fraction := strings.TrimRight(fmt.Sprintf("%06d", rate % RateScale), "0")
+	if fraction == "" {
+		return strconv.FormatInt(rate / RateScale, 10)
+	}
+
+	return strconv.FormatInt(rate / RateScale, 10) + "." + fraction
+}
+
+// Convert returns amount of to currency the customer gets for amount of from currency and
+// the rate applied in millionths of to per unit of from. Selling the base currency uses
+// bid, buying it uses inverse of ask rounded down to millionths, the amount is converted
+// by the returned rate exactly and rounded down to minor units of to currency.
+func (t *Table) Convert(amount types.Money, from types.Currency, to types.Currency) (types.Money, int64, error) {
+	fromCurrency, err := money.Lookup(from)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	toCurrency, err := money.Lookup(to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var rate int64
+	if value, ok := t.rates[pair(from, to)]; ok {
+		rate = value.Bid
+	} else if value, ok := t.rates[pair(to, from)]; ok {
+		rate = RateScale * RateScale / value.Ask
+	} else {
+		return 0, 0, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+
+	// converted = amount * rate * 10^toMinor / (scale * 10^fromMinor)
+	numerator := big.NewInt(int64(amount))
+	numerator.Mul(numerator, big.NewInt(rate))
+	numerator.Mul(numerator, pow10(toCurrency.Minor))
+	denominator := pow10(fromCurrency.Minor)
+	denominator.Mul(denominator, big.NewInt(RateScale))
+
+	converted := numerator.Quo(numerator, denominator)
+	if !converted.IsInt64() {
+		return 0, 0, ErrAmountTooLarge
+	}
+
+	return types.Money(converted.Int64()), rate, nil
+}
+
+func pair(base types.Currency, quote types.Currency) string {
+	return string(base) + "/" + string(quote)
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
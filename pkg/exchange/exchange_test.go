@@ -0,0 +1,75 @@
+package exchange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestTable_Convert_spread(t *testing.T) {
+	table, err := Load(strings.NewReader(`
+# test rates
+rate;USD;TJS;10.90;11.05
+rate;USD;JPY;150;151
+`))
+	if err != nil {
+		t.Errorf("Load(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		amount		types.Money
+		from		types.Currency
+		to			types.Currency
+		want		types.Money
+		wantRate	int64
+	} {
+		{ amount: 100_00,	from: "USD",	to: "TJS",	want: 1_090_00,	wantRate: 10_900_000 },
+		{ amount: 1_105_00,	from: "TJS",	to: "USD",	want: 99_99,	wantRate: 90_497 },
+		{ amount: 1_00,		from: "TJS",	to: "USD",	want: 9,		wantRate: 90_497 },
+		{ amount: 1_01,		from: "USD",	to: "JPY",	want: 151,		wantRate: 150_000_000 },
+		{ amount: 100,		from: "JPY",	to: "USD",	want: 66,		wantRate: 6_622 },
+	}
+
+	for _, test := range tests {
+		converted, rate, err := table.Convert(test.amount, test.from, test.to)
+		if err != nil || converted != test.want || rate != test.wantRate {
+			t.Errorf("Convert(%d %s to %s): got %d at %d, want %d at %d, error = %v", test.amount, test.from, test.to, converted, rate, test.want, test.wantRate, err)
+		}
+	}
+
+	_, _, err = table.Convert(100, "TJS", "EUR")
+	if !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("Convert(): must return ErrRateNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestLoad_fail(t *testing.T) {
+	tests := []string {
+		"rate;USD;TJS;11.05",
+		"rate;USD;TJS;11.05;10.90",
+		"rate;USD;TJS;0;10.90",
+		"rate;USD;USD;1;1",
+		"rate;USD;XYZ;1;1",
+		"rate;USD;TJS;10.9000001;11",
+		"rate;USD;TJS;1e1;11",
+		"rate;USD;TJS;10;11\nrate;TJS;USD;0.09;0.1",
+	}
+
+	for _, test := range tests {
+		_, err := Load(strings.NewReader(test))
+		if !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("Load(%q): must return ErrInvalidTable, but error = %v", test, err)
+		}
+	}
+}
+
+func TestFormatRate_success(t *testing.T) {
+	for rate, want := range map[int64]string{ 10_900_000: "10.9", 90_497: "0.090497", 150_000_000: "150" } {
+		if got := FormatRate(rate); got != want {
+			t.Errorf("FormatRate(%d): got %q, want %q", rate, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package fees
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// PercentScale - percents of rules are integers in basis points, 10000 is the whole amount
+const PercentScale = 10_000
+
+// percentPlaces - decimals of percents, PercentScale is 100 percents with 2 decimals
+const percentPlaces = 2
+
+// AnyCategory - category of rules applied when no rule of the payment category matches
+const AnyCategory types.PaymentCategory = "*"
+
+// ErrInvalidSchedule - fee rules are malformed
+var ErrInvalidSchedule = errors.New("Invalid fee schedule")
+
+// Rule - fee of payments in the currency and of the category with amount in the band [From, To),
+// amounts are in minor units of Currency
+type Rule struct {
+	Currency	types.Currency			// empty only for free rules, they match every currency
+	Category	types.PaymentCategory	// AnyCategory matches every category
+	From		types.Money
+	To			types.Money				// 0 means the band has no upper bound
+	Fixed		types.Money
+	Percent		int64					// basis points of the amount, rounded up
+	Min			types.Money
+	Max			types.Money				// 0 means the fee is not capped
+	Free		bool
+}
+
+// Matches reports whether the rule applies to the payment
+func (r Rule) Matches(currency types.Currency, category types.PaymentCategory, amount types.Money) bool {
+	if r.Currency != "" && r.Currency != currency {
+		return false
+	}
+
+	if r.Category != category && r.Category != AnyCategory {
+		return false
+	}
+
+	return amount >= r.From && (r.To == 0 || amount < r.To)
+}
+
+// Fee returns fixed part plus percent of the amount bounded by Min and Max
+func (r Rule) Fee(amount types.Money) (types.Money, error) {
+	if r.Free {
+		return 0, nil
+	}
+
+	// split so that amount * percent can't overflow
+	whole := amount / PercentScale * types.Money(r.Percent)
+	part := (amount % PercentScale * types.Money(r.Percent) + PercentScale - 1) / PercentScale
+
+	fee, err := r.Fixed.Add(whole + part)
+	if err != nil {
+		return 0, err
+	}
+
+	if fee < r.Min {
+		fee = r.Min
+	}
+
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+
+	return fee, nil
+}
+
+// Schedule - fee rules, rules of the payment category are tried before AnyCategory
+// ones and the first matching rule applies, payments matching no rule are free
+type Schedule struct {
+	rules	[]Rule
+}
+
+// NewSchedule checks rules and creates schedule of them
+func NewSchedule(rules []Rule) (*Schedule, error) {
+	for i, rule := range rules {
+		if strings.TrimSpace(string(rule.Category)) == "" {
+			return nil, fmt.Errorf("%w: rule %d: category is required", ErrInvalidSchedule, i + 1)
+		}
+
+		if rule.Currency == "" && !rule.Free {
+			return nil, fmt.Errorf("%w: rule %d: currency is required", ErrInvalidSchedule, i + 1)
+		}
+
+		if rule.Currency != "" {
+			_, err := money.Lookup(rule.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %d: %v", ErrInvalidSchedule, i + 1, err)
+			}
+		}
+
+		if rule.From < 0 || (rule.To != 0 && rule.To <= rule.From) {
+			return nil, fmt.Errorf("%w: rule %d: bad band %d-%d", ErrInvalidSchedule, i + 1, rule.From, rule.To)
+		}
+
+		if rule.Fixed < 0 || rule.Percent < 0 || rule.Percent > PercentScale {
+			return nil, fmt.Errorf("%w: rule %d: fixed fee must not be negative and percent must be between 0 and 100", ErrInvalidSchedule, i + 1)
+		}
+
+		if rule.Min < 0 || (rule.Max != 0 && rule.Max < rule.Min) {
+			return nil, fmt.Errorf("%w: rule %d: bad bounds %d-%d", ErrInvalidSchedule, i + 1, rule.Min, rule.Max)
+		}
+	}
+
+	return &Schedule{ rules: append([]Rule(nil), rules...) }, nil
+}
+
+// Load reads schedule from lines like:
+//	# comment
+//	free;salary							- payments of the category are free in every currency
+//	fee;TJS;auto;0;1000;1;1.5;2;50		- currency, category, band from and to, fixed fee, percent, min and max fee
+//	fee;TJS;*;0;0;0;1;0;0				- every other payment in TJS pays 1%
+// Amounts are decimals in the currency, percents have up to 2 decimals, zero upper bounds are not limited.
+func Load(reader io.Reader) (*Schedule, error) {
+	var rules []Rule
+
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		switch {
+		case fields[0] == "free" && len(fields) == 2:
+			rules = append(rules, Rule{ Category: types.PaymentCategory(fields[1]), Free: true })
+		case fields[0] == "fee" && len(fields) == 9:
+			rule, err := parseRule(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidSchedule, line, err)
+			}
+
+			rules = append(rules, rule)
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown record %q", ErrInvalidSchedule, line, text)
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSchedule(rules)
+}
+
+// Match returns rule applied to the payment in minor units of the currency
+func (s *Schedule) Match(currency types.Currency, category types.PaymentCategory, amount types.Money) (Rule, bool) {
+	for _, rule := range s.rules {
+		if rule.Category == category && rule.Matches(currency, category, amount) {
+			return rule, true
+		}
+	}
+
+	for _, rule := range s.rules {
+		if rule.Category == AnyCategory && rule.Matches(currency, category, amount) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Fee returns fee of the payment in minor units of the currency, zero if no rule matches
+func (s *Schedule) Fee(currency types.Currency, category types.PaymentCategory, amount types.Money) (types.Money, error) {
+	rule, ok := s.Match(currency, category, amount)
+	if !ok {
+		return 0, nil
+	}
+
+	return rule.Fee(amount)
+}
+
+// ParsePercent parses decimal percent like 1.5 into basis points
+func ParsePercent(value string) (int64, error) {
+	percent, err := money.ParseDecimal(value, percentPlaces)
+	if err != nil || percent < 0 {
+		return 0, fmt.Errorf("%w: percent %q", ErrInvalidSchedule, value)
+	}
+
+	return percent, nil
+}
+
+// parseRule parses currency, category, band, fixed fee, percent and bounds of a fee record
+func parseRule(fields []string) (Rule, error) {
+	currency := types.Currency(fields[0])
+
+	var amounts [5]types.Money
+	for i, field := range []string{ fields[2], fields[3], fields[4], fields[6], fields[7] } {
+		value, err := money.Parse(field, currency)
+		if err != nil {
+			return Rule{}, err
+		}
+
+		if value < 0 {
+			return Rule{}, fmt.Errorf("bad amount %q", field)
+		}
+
+		amounts[i] = value
+	}
+
+	percent, err := ParsePercent(fields[5])
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule {
+		Currency:	currency,
+		Category:	types.PaymentCategory(fields[1]),
+		From:		amounts[0],
+		To:			amounts[1],
+		Fixed:		amounts[2],
+		Percent:	percent,
+		Min:		amounts[3],
+		Max:		amounts[4],
+	}, nil
+}
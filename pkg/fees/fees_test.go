@@ -0,0 +1,112 @@
+package fees
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestSchedule_Fee_bands(t *testing.T) {
+	schedule, err := Load(strings.NewReader(`
+# test fees
+free;salary
+fee;TJS;auto;0;1000;1;1.5;2;50
+fee;TJS;auto;1000;0;0;0.5;0;0
+fee;TJS;*;0;0;0.50;0;0;0
+fee;JPY;auto;0;0;100;0;0;0
+`))
+	if err != nil {
+		t.Errorf("Load(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		currency	types.Currency
+		category	types.PaymentCategory
+		amount		types.Money
+		want		types.Money
+	} {
+		{ currency: "TJS",	category: "salary",	amount: 1_000_00,	want: 0 },
+		{ currency: "USD",	category: "salary",	amount: 1_000_00,	want: 0 },
+		{ currency: "TJS",	category: "auto",	amount: 1_00,		want: 200 },
+		{ currency: "TJS",	category: "auto",	amount: 500_00,		want: 850 },
+		{ currency: "TJS",	category: "auto",	amount: 999_99,		want: 1_600 },
+		{ currency: "TJS",	category: "auto",	amount: 1_000_00,	want: 500 },
+		{ currency: "TJS",	category: "auto",	amount: 1_000_01,	want: 501 },
+		{ currency: "TJS",	category: "food",	amount: 1_000_00,	want: 50 },
+		{ currency: "JPY",	category: "auto",	amount: 1_000,		want: 100 },
+		{ currency: "USD",	category: "auto",	amount: 1_000_00,	want: 0 },
+	}
+
+	for _, test := range tests {
+		fee, err := schedule.Fee(test.currency, test.category, test.amount)
+		if err != nil || fee != test.want {
+			t.Errorf("Fee(%s, %s, %d): got %d, want %d, error = %v", test.currency, test.category, test.amount, fee, test.want, err)
+		}
+	}
+
+	empty, _ := NewSchedule(nil)
+	fee, err := empty.Fee("TJS", "auto", 1_000_00)
+	if err != nil || fee != 0 {
+		t.Errorf("Fee(): payments matching no rule must be free, got %d, error = %v", fee, err)
+		return
+	}
+}
+
+func TestRule_Fee_capped(t *testing.T) {
+	rule := Rule{ Category: AnyCategory, Fixed: 100, Percent: PercentScale, Max: 10_000 }
+
+	fee, err := rule.Fee(1_000_00)
+	if err != nil || fee != 10_000 {
+		t.Errorf("Fee(): got %d, error = %v", fee, err)
+		return
+	}
+
+	rule.Max = 0
+	_, err = rule.Fee(math.MaxInt64 - 50)
+	if !errors.Is(err, types.ErrOverflow) {
+		t.Errorf("Fee(): must return ErrOverflow, but error = %v", err)
+		return
+	}
+}
+
+func TestNewSchedule_currency(t *testing.T) {
+	_, err := NewSchedule([]Rule{ { Category: "auto", Fixed: 100 } })
+	if !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("NewSchedule(): fee without currency must return ErrInvalidSchedule, but error = %v", err)
+		return
+	}
+
+	_, err = NewSchedule([]Rule{ { Category: "salary", Free: true } })
+	if err != nil {
+		t.Errorf("NewSchedule(): free rule may match every currency, error = %v", err)
+		return
+	}
+}
+
+func TestLoad_fail(t *testing.T) {
+	tests := []string {
+		"fee;TJS;auto;0;0;1;1",
+		"free;auto;1",
+		"fee;TJS;;0;0;1;1;0;0",
+		"fee;TJS;auto;1;0.50;0;1;0;0",
+		"fee;TJS;auto;0;0;-1;1;0;0",
+		"fee;TJS;auto;0;0;0;100.01;0;0",
+		"fee;TJS;auto;0;0;0;1.005;0;0",
+		"fee;TJS;auto;0;0;0;-1;0;0",
+		"fee;TJS;auto;0;0;0;1;5;1",
+		"fee;TJS;auto;0;0;0.005;1;0;0",
+		"fee;XYZ;auto;0;0;1;1;0;0",
+		"fee;auto;0;0;1;1;0;0;0",
+		"rate;USD;TJS;10;11",
+	}
+
+	for _, test := range tests {
+		_, err := Load(strings.NewReader(test))
+		if !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("Load(%q): must return ErrInvalidSchedule, but error = %v", test, err)
+		}
+	}
+}
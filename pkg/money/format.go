@@ -0,0 +1,178 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrInvalidAmount - amount is not a decimal number with minor units of the currency
+var ErrInvalidAmount = errors.New("Invalid amount")
+
+// ErrAmountOverflow - amount doesn't fit into types.Money
+var ErrAmountOverflow = errors.New("Amount is out of range")
+
+// ErrUnknownLocale - locale is not in Locales
+var ErrUnknownLocale = errors.New("Unknown locale")
+
+// Locale - how amounts are written for people
+type Locale struct {
+	Decimal	string	// separator of minor units
+	Group	string	// separator of thousands, empty disables grouping
+	Symbol	bool	// currency symbol instead of the code when the currency has one
+}
+
+// Predefined locales
+var (
+	Plain	= Locale{ Decimal: "." }				// 1234.50 TJS, used in files read by programs
+	English	= Locale{ Decimal: ".", Group: "," }	// 1,234.50 TJS
+	Russian	= Locale{ Decimal: ",", Group: " " }	// 1 234,50 TJS, also used in Tajikistan
+)
+
+// Locales - locales by name
+var Locales = map[string]Locale {
+	"plain":	Plain,
+	"en":		English,
+	"ru":		Russian,
+	"tg":		Russian,
+}
+
+// LookupLocale returns locale by name, with symbols if the name ends with +symbol
+func LookupLocale(name string) (Locale, error) {
+	symbol := strings.HasSuffix(name, "+symbol")
+	locale, ok := Locales[strings.TrimSuffix(name, "+symbol")]
+	if !ok {
+		return Locale{}, fmt.Errorf("%w: %q", ErrUnknownLocale, name)
+	}
+
+	locale.Symbol = symbol
+	return locale, nil
+}
+
+// Parse reads decimal amount like 12.50 or -3 into minor units of the currency, it accepts
+// digits, optional minus and at most as many fraction digits as the currency has minor units
+func Parse(value string, code types.Currency) (types.Money, error) {
+	currency, err := Lookup(code)
+	if err != nil {
+		return 0, err
+	}
+
+	amount, err := ParseDecimal(value, currency.Minor)
+	if err != nil {
+		return 0, err
+	}
+
+	return types.Money(amount), nil
+}
+
+// ParseDecimal reads decimal number with at most places fraction digits into an integer
+// scaled by 10^places, so ParseDecimal("1.5", 2) is 150
+func ParseDecimal(value string, places int) (int64, error) {
+	number := strings.TrimPrefix(value, "-")
+	parts := strings.Split(number, ".")
+	if len(parts) > 2 || !digits(parts[0]) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = parts[1]
+		if !digits(fraction) || len(fraction) > places {
+			return 0, fmt.Errorf("%w: %q must have at most %d digits after the point", ErrInvalidAmount, value, places)
+		}
+	}
+
+	digits := parts[0] + fraction + strings.Repeat("0", places - len(fraction))
+	if number != value {
+		digits = "-" + digits
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOverflow, value)
+	}
+
+	return amount, nil
+}
+
+// Format writes minor units of the currency by the locale, unknown currencies have no minor units
+func Format(value types.Money, code types.Currency, locale Locale) string {
+	currency, err := Lookup(code)
+	if err != nil {
+		currency = Currency{ Code: code }
+	}
+
+	digits := strconv.FormatUint(magnitude(value), 10)
+	if len(digits) <= currency.Minor {
+		digits = strings.Repeat("0", currency.Minor - len(digits) + 1) + digits
+	}
+
+	point := len(digits) - currency.Minor
+	number := group(digits[:point], locale.Group)
+	if currency.Minor > 0 {
+		number += locale.Decimal + digits[point:]
+	}
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+
+	switch {
+	case locale.Symbol && currency.Symbol != "" && currency.SymbolFirst:
+		return sign + currency.Symbol + number
+	case locale.Symbol && currency.Symbol != "":
+		return sign + number + " " + currency.Symbol
+	default:
+		return sign + number + " " + string(currency.Code)
+	}
+}
+
+// Format writes amount by the locale
+func (a Amount) Format(locale Locale) string {
+	return Format(a.Value, a.Currency, locale)
+}
+
+// magnitude returns absolute value, it doesn't overflow for the smallest Money
+func magnitude(value types.Money) uint64 {
+	if value < 0 {
+		return uint64(-(value + 1)) + 1
+	}
+
+	return uint64(value)
+}
+
+// group inserts separator between thousands of the whole part
+func group(whole string, separator string) string {
+	if separator == "" || len(whole) <= 3 {
+		return whole
+	}
+
+	head := len(whole) % 3
+	if head == 0 {
+		head = 3
+	}
+
+	parts := []string{ whole[:head] }
+	for i := head; i < len(whole); i += 3 {
+		parts = append(parts, whole[i : i + 3])
+	}
+
+	return strings.Join(parts, separator)
+}
+
+func digits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
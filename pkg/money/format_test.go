@@ -0,0 +1,101 @@
+package money
+
+import (
+	"errors"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestParse_success(t *testing.T) {
+	tests := []struct {
+		value		string
+		currency	types.Currency
+		want		types.Money
+	} {
+		{ value: "12.50",	currency: "TJS",	want: 1250 },
+		{ value: "12.5",	currency: "TJS",	want: 1250 },
+		{ value: "12",		currency: "TJS",	want: 1200 },
+		{ value: "-0.05",	currency: "USD",	want: -5 },
+		{ value: "1250",	currency: "JPY",	want: 1250 },
+		{ value: "1.250",	currency: "KWD",	want: 1250 },
+		{ value: "92233720368547758.07",	currency: "TJS",	want: 9223372036854775807 },
+		{ value: "-92233720368547758.08",	currency: "TJS",	want: -9223372036854775808 },
+	}
+
+	for _, test := range tests {
+		amount, err := Parse(test.value, test.currency)
+		if err != nil || amount != test.want {
+			t.Errorf("Parse(%q, %s): got %d, want %d, error = %v", test.value, test.currency, amount, test.want, err)
+		}
+	}
+}
+
+func TestParse_fail(t *testing.T) {
+	tests := []struct {
+		value		string
+		currency	types.Currency
+		want		error
+	} {
+		{ value: "",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "12.",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: ".5",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "12.505",		currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "1.5",			currency: "JPY",	want: ErrInvalidAmount },
+		{ value: "+12",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "1,000",		currency: "TJS",	want: ErrInvalidAmount },
+		{ value: " 12",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "1e3",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "--1",			currency: "TJS",	want: ErrInvalidAmount },
+		{ value: "92233720368547758.08",	currency: "TJS",	want: ErrAmountOverflow },
+		{ value: "1",			currency: "XYZ",	want: ErrUnknownCurrency },
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.value, test.currency)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Parse(%q, %s): must return %v, but error = %v", test.value, test.currency, test.want, err)
+		}
+	}
+}
+
+func TestFormat_locales(t *testing.T) {
+	symbols := English
+	symbols.Symbol = true
+
+	tests := []struct {
+		value		types.Money
+		currency	types.Currency
+		locale		Locale
+		want		string
+	} {
+		{ value: 123456789,	currency: "TJS",	locale: Plain,		want: "1234567.89 TJS" },
+		{ value: 123456789,	currency: "TJS",	locale: English,	want: "1,234,567.89 TJS" },
+		{ value: 123456789,	currency: "TJS",	locale: Russian,	want: "1 234 567,89 TJS" },
+		{ value: -123456,	currency: "USD",	locale: symbols,	want: "-$1,234.56" },
+		{ value: 5,			currency: "TJS",	locale: symbols,	want: "0.05 SM" },
+		{ value: 1000,		currency: "JPY",	locale: symbols,	want: "¥1,000" },
+		{ value: 1000,		currency: "AED",	locale: symbols,	want: "10.00 AED" },
+		{ value: 1000,		currency: "XYZ",	locale: English,	want: "1,000 XYZ" },
+		{ value: -9223372036854775808,	currency: "TJS",	locale: English,	want: "-92,233,720,368,547,758.08 TJS" },
+	}
+
+	for _, test := range tests {
+		if got := Format(test.value, test.currency, test.locale); got != test.want {
+			t.Errorf("Format(%d, %s): got %q, want %q", test.value, test.currency, got, test.want)
+		}
+	}
+}
+
+func TestLookupLocale_symbol(t *testing.T) {
+	locale, err := LookupLocale("ru+symbol")
+	if err != nil || locale.Decimal != "," || !locale.Symbol {
+		t.Errorf("LookupLocale(): got %+v, error = %v", locale, err)
+		return
+	}
+
+	_, err = LookupLocale("xx")
+	if !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("LookupLocale(): must return ErrUnknownLocale, but error = %v", err)
+		return
+	}
+}
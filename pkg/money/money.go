@@ -0,0 +1,126 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrUnknownCurrency - currency code is not in the ISO 4217 table
+var ErrUnknownCurrency = errors.New("Unknown currency")
+
+// ErrCurrencyMismatch - amounts in different currencies can't be combined
+var ErrCurrencyMismatch = errors.New("Currencies of amounts don't match")
+
+// Currency - ISO 4217 currency and the number of its minor units
+type Currency struct {
+	Code		types.Currency	// alphabetic code, like TJS
+	Minor		int				// digits after the decimal point, 2 for dirams of TJS
+	Symbol		string			// sign used instead of the code, may be empty
+	SymbolFirst	bool			// symbol is written before the number, like $1.00
+}
+
+// MaxMinor - most minor units among supported currencies, amounts with this many
+// fraction digits can be checked before their currency is known
+const MaxMinor = 3
+
+// currencies supported by the wallet
+var currencies = map[types.Currency]Currency {
+	"TJS":	{ Code: "TJS", Minor: 2, Symbol: "SM" },
+	"USD":	{ Code: "USD", Minor: 2, Symbol: "$", SymbolFirst: true },
+	"EUR":	{ Code: "EUR", Minor: 2, Symbol: "€", SymbolFirst: true },
+	"GBP":	{ Code: "GBP", Minor: 2, Symbol: "£", SymbolFirst: true },
+	"RUB":	{ Code: "RUB", Minor: 2, Symbol: "₽" },
+	"CNY":	{ Code: "CNY", Minor: 2, Symbol: "¥", SymbolFirst: true },
+	"KZT":	{ Code: "KZT", Minor: 2, Symbol: "₸" },
+	"UZS":	{ Code: "UZS", Minor: 2 },
+	"KGS":	{ Code: "KGS", Minor: 2 },
+	"TRY":	{ Code: "TRY", Minor: 2, Symbol: "₺", SymbolFirst: true },
+	"AED":	{ Code: "AED", Minor: 2 },
+	"JPY":	{ Code: "JPY", Minor: 0, Symbol: "¥", SymbolFirst: true },
+	"KRW":	{ Code: "KRW", Minor: 0, Symbol: "₩", SymbolFirst: true },
+	"KWD":	{ Code: "KWD", Minor: 3 },
+	"BHD":	{ Code: "BHD", Minor: 3 },
+}
+
+// Lookup returns currency by its alphabetic code
+func Lookup(code types.Currency) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+
+	return currency, nil
+}
+
+// Amount - money in minor units of the currency
+type Amount struct {
+	Value		types.Money
+	Currency	types.Currency
+}
+
+// New creates amount of value minor units in the currency
+func New(value types.Money, currency types.Currency) Amount {
+	return Amount{ Value: value, Currency: currency }
+}
+
+// Add returns sum of amounts in the same currency, OverflowError if it doesn't fit
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+
+	value, err := a.Value.Add(b.Value)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	return Amount{ Value: value, Currency: a.Currency }, nil
+}
+
+// Sub returns difference of amounts in the same currency, OverflowError if it doesn't fit
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+
+	value, err := a.Value.Sub(b.Value)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	return Amount{ Value: value, Currency: a.Currency }, nil
+}
+
+// String formats amount with minor units of the currency, like 12.50 TJS
+func (a Amount) String() string {
+	return Format(a.Value, a.Currency, Plain)
+}
+
+// Totals - sums of amounts per currency
+type Totals map[types.Currency]types.Money
+
+// Currencies returns currencies of the totals in alphabetic order
+func (t Totals) Currencies() []types.Currency {
+	codes := make([]types.Currency, 0, len(t))
+	for currency := range t {
+		codes = append(codes, currency)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+
+	return codes
+}
+
+// Amounts returns totals as amounts in alphabetic order of currencies
+func (t Totals) Amounts() []Amount {
+	var amounts []Amount
+	for _, currency := range t.Currencies() {
+		amounts = append(amounts, New(t[currency], currency))
+	}
+
+	return amounts
+}
@@ -0,0 +1,86 @@
+package money
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestLookup_success(t *testing.T) {
+	tests := []struct {
+		code	types.Currency
+		minor	int
+	} {
+		{ code: "TJS",	minor: 2 },
+		{ code: "JPY",	minor: 0 },
+		{ code: "KWD",	minor: 3 },
+	}
+
+	for _, test := range tests {
+		currency, err := Lookup(test.code)
+		if err != nil || currency.Minor != test.minor {
+			t.Errorf("Lookup(%q): got %+v, error = %v", test.code, currency, err)
+		}
+	}
+
+	_, err := Lookup("XYZ")
+	if !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Lookup(): must return ErrUnknownCurrency, but error = %v", err)
+		return
+	}
+
+	for code, currency := range currencies {
+		if currency.Minor > MaxMinor {
+			t.Errorf("Lookup(%q): minor units %d are more than MaxMinor", code, currency.Minor)
+		}
+	}
+}
+
+func TestAmount_Add_mismatch(t *testing.T) {
+	sum, err := New(150, "TJS").Add(New(50, "TJS"))
+	if err != nil || sum != New(200, "TJS") {
+		t.Errorf("Add(): got %v, error = %v", sum, err)
+		return
+	}
+
+	_, err = New(150, "TJS").Add(New(50, "USD"))
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add(): must return ErrCurrencyMismatch, but error = %v", err)
+		return
+	}
+
+	_, err = New(150, "TJS").Sub(New(50, "USD"))
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub(): must return ErrCurrencyMismatch, but error = %v", err)
+		return
+	}
+}
+
+func TestAmount_String_minorUnits(t *testing.T) {
+	tests := []struct {
+		amount	Amount
+		want	string
+	} {
+		{ amount: New(1250, "TJS"),	want: "12.50 TJS" },
+		{ amount: New(-5, "USD"),	want: "-0.05 USD" },
+		{ amount: New(1250, "JPY"),	want: "1250 JPY" },
+		{ amount: New(1250, "KWD"),	want: "1.250 KWD" },
+	}
+
+	for _, test := range tests {
+		if got := test.amount.String(); got != test.want {
+			t.Errorf("String(): got %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestTotals_Amounts_sorted(t *testing.T) {
+	totals := Totals{ "USD": 150, "TJS": 200 }
+
+	want := []Amount{ New(200, "TJS"), New(150, "USD") }
+	if !reflect.DeepEqual(totals.Amounts(), want) {
+		t.Errorf("Amounts(): got %v, want %v", totals.Amounts(), want)
+		return
+	}
+}
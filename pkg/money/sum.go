@@ -0,0 +1,89 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrSumOverflow - sum doesn't fit into types.Money, it unwraps to types.ErrOverflow
+var ErrSumOverflow = fmt.Errorf("%w: sum is out of range", types.ErrOverflow)
+
+// Sum - 128 bit accumulator of money, it doesn't overflow before 2^64 additions
+type Sum struct {
+	hi	int64
+	lo	uint64
+}
+
+// Add adds value to the sum
+func (s *Sum) Add(value types.Money) {
+	high := int64(0)
+	if value < 0 {
+		high = -1
+	}
+
+	s.add(high, uint64(value))
+}
+
+// Merge adds other sum
+func (s *Sum) Merge(other Sum) {
+	s.add(other.hi, other.lo)
+}
+
+// Money returns the sum or ErrSumOverflow if it doesn't fit into types.Money
+func (s Sum) Money() (types.Money, error) {
+	if (s.hi == 0 && s.lo <= math.MaxInt64) || (s.hi == -1 && s.lo > math.MaxInt64) {
+		return types.Money(int64(s.lo)), nil
+	}
+
+	return 0, ErrSumOverflow
+}
+
+func (s *Sum) add(hi int64, lo uint64) {
+	sum, carry := bits.Add64(s.lo, lo, 0)
+	s.lo = sum
+	s.hi += hi + int64(carry)
+}
+
+// Sums - wide sums per currency
+type Sums map[types.Currency]*Sum
+
+// Add adds the amount to the sum of its currency
+func (s Sums) Add(amount Amount) {
+	sum, ok := s[amount.Currency]
+	if !ok {
+		sum = &Sum{}
+		s[amount.Currency] = sum
+	}
+
+	sum.Add(amount.Value)
+}
+
+// Merge adds sums of other currencies
+func (s Sums) Merge(other Sums) {
+	for currency, value := range other {
+		sum, ok := s[currency]
+		if !ok {
+			sum = &Sum{}
+			s[currency] = sum
+		}
+
+		sum.Merge(*value)
+	}
+}
+
+// Totals returns sums as money, ErrSumOverflow if any of them doesn't fit
+func (s Sums) Totals() (Totals, error) {
+	totals := make(Totals, len(s))
+	for currency, sum := range s {
+		value, err := sum.Money()
+		if err != nil {
+			return nil, err
+		}
+
+		totals[currency] = value
+	}
+
+	return totals, nil
+}
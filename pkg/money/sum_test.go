@@ -0,0 +1,64 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestSum_Money_wide(t *testing.T) {
+	sum := Sum{}
+	sum.Add(math.MaxInt64)
+	sum.Add(math.MaxInt64)
+
+	_, err := sum.Money()
+	if err != ErrSumOverflow || !errors.Is(err, types.ErrOverflow) {
+		t.Errorf("Money(): must return ErrSumOverflow, but error = %v", err)
+		return
+	}
+
+	// intermediate overflow is fine while the result fits
+	sum.Add(-math.MaxInt64)
+	sum.Add(-math.MaxInt64)
+	sum.Add(math.MinInt64)
+
+	value, err := sum.Money()
+	if err != nil || value != math.MinInt64 {
+		t.Errorf("Money(): got %v, error = %v", value, err)
+		return
+	}
+
+	other := Sum{}
+	other.Add(-5)
+	sum.Merge(other)
+	_, err = sum.Money()
+	if !errors.Is(err, types.ErrOverflow) {
+		t.Errorf("Money(): must return ErrOverflow below min, but error = %v", err)
+		return
+	}
+}
+
+func TestSums_Totals_success(t *testing.T) {
+	sums := Sums{}
+	sums.Add(New(100, "USD"))
+	sums.Add(New(-30, "TJS"))
+
+	other := Sums{}
+	other.Add(New(50, "USD"))
+	sums.Merge(other)
+
+	totals, err := sums.Totals()
+	if err != nil || !reflect.DeepEqual(totals, Totals{ "USD": 150, "TJS": -30 }) {
+		t.Errorf("Totals(): got %v, error = %v", totals, err)
+		return
+	}
+
+	sums.Add(New(math.MaxInt64, "USD"))
+	_, err = sums.Totals()
+	if !errors.Is(err, types.ErrOverflow) {
+		t.Errorf("Totals(): must return ErrOverflow, but error = %v", err)
+		return
+	}
+}
@@ -0,0 +1,186 @@
+package operator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrOperatorNotFound - no operator serves the phone number
+var ErrOperatorNotFound = errors.New("Operator not found")
+
+// ErrInvalidRegistry - operators or ranges are malformed
+var ErrInvalidRegistry = errors.New("Invalid operator registry")
+
+// Operator - mobile operator and amounts of top-ups it accepts, zero means no limit
+type Operator struct {
+	Name		string
+	MinAmount	types.Money
+	MaxAmount	types.Money
+}
+
+// Range - numbers whose first digits are between From and To belong to the operator,
+// both are digits of E.164 number without plus and have the same length
+type Range struct {
+	From		string
+	To			string
+	Operator	string
+}
+
+// Registry - operators and their number ranges, the longest matching range wins
+type Registry struct {
+	operators	map[string]Operator
+	ranges		[]Range
+}
+
+// Default - operators of Tajikistan
+var Default = mustRegistry(
+	[]Operator {
+		{ Name: "Tcell",			MinAmount: 1_00,	MaxAmount: 5_000_00 },
+		{ Name: "Babilon-Mobile",	MinAmount: 1_00,	MaxAmount: 5_000_00 },
+		{ Name: "MegaFon",			MinAmount: 1_00,	MaxAmount: 5_000_00 },
+		{ Name: "ZET-Mobile",		MinAmount: 1_00,	MaxAmount: 5_000_00 },
+		{ Name: "O-Mobile",			MinAmount: 1_00,	MaxAmount: 3_000_00 },
+	},
+	[]Range {
+		{ From: "99292",	To: "99293",	Operator: "Tcell" },
+		{ From: "99298",	To: "99298",	Operator: "Babilon-Mobile" },
+		{ From: "992918",	To: "992918",	Operator: "Babilon-Mobile" },
+		{ From: "99288",	To: "99288",	Operator: "MegaFon" },
+		{ From: "99290",	To: "99290",	Operator: "MegaFon" },
+		{ From: "99291",	To: "99291",	Operator: "ZET-Mobile" },
+		{ From: "99255",	To: "99255",	Operator: "O-Mobile" },
+	},
+)
+
+// NewRegistry checks operators and ranges and creates registry of them
+func NewRegistry(operators []Operator, ranges []Range) (*Registry, error) {
+	registry := &Registry{ operators: make(map[string]Operator, len(operators)) }
+	for _, operator := range operators {
+		if operator.Name == "" {
+			return nil, fmt.Errorf("%w: operator without name", ErrInvalidRegistry)
+		}
+
+		if _, ok := registry.operators[operator.Name]; ok {
+			return nil, fmt.Errorf("%w: operator %q is repeated", ErrInvalidRegistry, operator.Name)
+		}
+
+		if operator.MinAmount < 0 || operator.MaxAmount < 0 || (operator.MaxAmount > 0 && operator.MinAmount > operator.MaxAmount) {
+			return nil, fmt.Errorf("%w: bad amounts of operator %q", ErrInvalidRegistry, operator.Name)
+		}
+
+		registry.operators[operator.Name] = operator
+	}
+
+	for _, value := range ranges {
+		if !digits(value.From) || len(value.From) != len(value.To) || !digits(value.To) || value.From > value.To {
+			return nil, fmt.Errorf("%w: bad range %s-%s", ErrInvalidRegistry, value.From, value.To)
+		}
+
+		if _, ok := registry.operators[value.Operator]; !ok {
+			return nil, fmt.Errorf("%w: range %s-%s of unknown operator %q", ErrInvalidRegistry, value.From, value.To, value.Operator)
+		}
+
+		registry.ranges = append(registry.ranges, value)
+	}
+
+	return registry, nil
+}
+
+// Load reads registry from lines like:
+//	# comment
+//	operator;Tcell;100;500000	- name, min and max amount
+//	range;99292;99293;Tcell		- first and last prefix, operator
+func Load(reader io.Reader) (*Registry, error) {
+	var operators []Operator
+	var ranges []Range
+
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		switch {
+		case fields[0] == "operator" && len(fields) == 4:
+			minAmount, minErr := strconv.ParseInt(fields[2], 10, 64)
+			maxAmount, maxErr := strconv.ParseInt(fields[3], 10, 64)
+			if minErr != nil || maxErr != nil {
+				return nil, fmt.Errorf("%w: line %d: bad amount", ErrInvalidRegistry, line)
+			}
+
+			operators = append(operators, Operator{ Name: fields[1], MinAmount: types.Money(minAmount), MaxAmount: types.Money(maxAmount) })
+
+		case fields[0] == "range" && len(fields) == 4:
+			ranges = append(ranges, Range{ From: fields[1], To: fields[2], Operator: fields[3] })
+
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown record %q", ErrInvalidRegistry, line, text)
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegistry(operators, ranges)
+}
+
+// Resolve returns operator of the number in E.164 form
+func (r *Registry) Resolve(phone types.Phone) (Operator, error) {
+	number := strings.TrimPrefix(string(phone), "+")
+
+	var found *Range
+	for i, value := range r.ranges {
+		if len(number) < len(value.From) {
+			continue
+		}
+
+		prefix := number[:len(value.From)]
+		if prefix < value.From || prefix > value.To {
+			continue
+		}
+
+		if found == nil || len(value.From) > len(found.From) {
+			found = &r.ranges[i]
+		}
+	}
+
+	if found == nil {
+		return Operator{}, fmt.Errorf("%w: %s", ErrOperatorNotFound, phone)
+	}
+
+	return r.operators[found.Operator], nil
+}
+
+func mustRegistry(operators []Operator, ranges []Range) *Registry {
+	registry, err := NewRegistry(operators, ranges)
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
+
+func digits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
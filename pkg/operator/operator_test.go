@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestRegistry_Resolve_default(t *testing.T) {
+	tests := []struct {
+		phone	types.Phone
+		want	string
+	} {
+		{ phone: "+992927452945",	want: "Tcell" },
+		{ phone: "+992937452945",	want: "Tcell" },
+		{ phone: "+992917452945",	want: "ZET-Mobile" },
+		{ phone: "+992918452945",	want: "Babilon-Mobile" },
+		{ phone: "+992887452945",	want: "MegaFon" },
+	}
+
+	for _, test := range tests {
+		operator, err := Default.Resolve(test.phone)
+		if err != nil || operator.Name != test.want {
+			t.Errorf("Resolve(%q): got %q, want %q, error = %v", test.phone, operator.Name, test.want, err)
+		}
+	}
+
+	_, err := Default.Resolve("+992117452945")
+	if !errors.Is(err, ErrOperatorNotFound) {
+		t.Errorf("Resolve(): must return ErrOperatorNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestLoad_success(t *testing.T) {
+	registry, err := Load(strings.NewReader(`
+# test operators
+operator;Alpha;500;10000
+operator;Beta;0;0
+range;7900;7909;Alpha
+range;79055;79055;Beta
+`))
+	if err != nil {
+		t.Errorf("Load(): error = %v", err)
+		return
+	}
+
+	operator, err := registry.Resolve("+79051234567")
+	if err != nil || operator != (Operator{ Name: "Alpha", MinAmount: 500, MaxAmount: 10_000 }) {
+		t.Errorf("Resolve(): wrong operator = %+v, error = %v", operator, err)
+		return
+	}
+
+	operator, err = registry.Resolve("+79055234567")
+	if err != nil || operator.Name != "Beta" {
+		t.Errorf("Resolve(): longest range must win, operator = %+v, error = %v", operator, err)
+		return
+	}
+}
+
+func TestLoad_fail(t *testing.T) {
+	files := []string {
+		"operator;Alpha;1",
+		"operator;Alpha;x;10",
+		"operator;Alpha;20;10",
+		"operator;Alpha;0;0\noperator;Alpha;0;0",
+		"operator;Alpha;0;0\nrange;7910;7900;Alpha",
+		"operator;Alpha;0;0\nrange;791;7900;Alpha",
+		"range;7900;7909;Alpha",
+		"prefix;7900;Alpha",
+	}
+
+	for _, file := range files {
+		_, err := Load(strings.NewReader(file))
+		if !errors.Is(err, ErrInvalidRegistry) {
+			t.Errorf("Load(%q): must return ErrInvalidRegistry, but error = %v", file, err)
+		}
+	}
+}
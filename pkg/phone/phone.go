@@ -0,0 +1,145 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrInvalidPhone - phone number can't be parsed or does not fit country rules
+var ErrInvalidPhone = errors.New("Invalid phone number")
+
+// Country - numbering rules of a country
+type Country struct {
+	Name	string	// ISO 3166 code, like TJ
+	Code	string	// calling code without plus, like 992
+	Lengths	[]int	// allowed lengths of the national number
+	Trunk	string	// prefix dialed before national number inside the country, may be empty
+}
+
+// Tajikistan numbering rules
+var Tajikistan = Country{ Name: "TJ", Code: "992", Lengths: []int{ 9 } }
+
+// Table - countries known to the parser, numbers without calling code belong to the home country
+type Table struct {
+	home		Country
+	countries	[]Country
+}
+
+// Default - table with Tajikistan as the only and home country
+var Default = &Table{ home: Tajikistan, countries: []Country{ Tajikistan } }
+
+// NewTable creates table of the home country and others
+func NewTable(home Country, others ...Country) (*Table, error) {
+	countries := append([]Country{ home }, others...)
+	for _, country := range countries {
+		if country.Name == "" || !digits(country.Code) || len(country.Code) > 3 || len(country.Lengths) == 0 {
+			return nil, fmt.Errorf("%w: bad rules of country %q", ErrInvalidPhone, country.Name)
+		}
+
+		for _, length := range country.Lengths {
+			if length <= 0 || len(country.Code) + length > 15 {
+				return nil, fmt.Errorf("%w: bad number length %d of country %q", ErrInvalidPhone, length, country.Name)
+			}
+		}
+
+		if country.Trunk != "" && !digits(country.Trunk) {
+			return nil, fmt.Errorf("%w: bad trunk prefix of country %q", ErrInvalidPhone, country.Name)
+		}
+	}
+
+	return &Table{ home: home, countries: countries }, nil
+}
+
+// Normalize parses the number by the Default table
+func Normalize(raw string) (types.Phone, error) {
+	return Default.Normalize(raw)
+}
+
+// Normalize returns the number in E.164 form like +992937452945, it accepts spaces,
+// dashes, dots and parentheses between digits, 00 instead of plus, calling code without
+// plus and national numbers of the home country
+func (t *Table) Normalize(raw string) (types.Phone, error) {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, raw)
+
+	international := false
+	switch {
+	case strings.HasPrefix(number, "+"):
+		international = true
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		international = true
+		number = number[2:]
+	}
+
+	if !digits(number) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPhone, raw)
+	}
+
+	if !international {
+		if fits(t.home, number) {
+			return types.Phone("+" + t.home.Code + number), nil
+		}
+
+		national := strings.TrimPrefix(number, t.home.Trunk)
+		if t.home.Trunk != "" && len(national) < len(number) && fits(t.home, national) {
+			return types.Phone("+" + t.home.Code + national), nil
+		}
+	}
+
+	for _, country := range t.countries {
+		if strings.HasPrefix(number, country.Code) && fits(country, number[len(country.Code):]) {
+			return types.Phone("+" + number), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q does not match any known country", ErrInvalidPhone, raw)
+}
+
+// Country returns country of the number
+func (t *Table) Country(phone types.Phone) (Country, error) {
+	normalized, err := t.Normalize(string(phone))
+	if err != nil {
+		return Country{}, err
+	}
+
+	number := string(normalized[1:])
+	for _, country := range t.countries {
+		if strings.HasPrefix(number, country.Code) && fits(country, number[len(country.Code):]) {
+			return country, nil
+		}
+	}
+
+	return Country{}, fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+}
+
+func fits(country Country, national string) bool {
+	for _, length := range country.Lengths {
+		if len(national) == length {
+			return true
+		}
+	}
+
+	return false
+}
+
+func digits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,90 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestNormalize_success(t *testing.T) {
+	numbers := []string {
+		"+992937452945",
+		"992937452945",
+		"+992 93 745 29 45",
+		"00992-93-745-29-45",
+		"(93) 745-29-45",
+		"937452945",
+	}
+
+	for _, number := range numbers {
+		phone, err := Normalize(number)
+		if err != nil {
+			t.Errorf("Normalize(%q): error = %v", number, err)
+			continue
+		}
+
+		if phone != "+992937452945" {
+			t.Errorf("Normalize(%q): got %v", number, phone)
+		}
+	}
+}
+
+func TestNormalize_invalid(t *testing.T) {
+	numbers := []string {
+		"",
+		"+",
+		"+992 93 745 29 4",
+		"+99293745294512",
+		"+7 912 345 67 89",
+		"93745294a",
+		"+992+937452945",
+	}
+
+	for _, number := range numbers {
+		_, err := Normalize(number)
+		if !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("Normalize(%q): must return ErrInvalidPhone, but error = %v", number, err)
+		}
+	}
+}
+
+func TestTable_Normalize_countries(t *testing.T) {
+	russia := Country{ Name: "RU", Code: "7", Lengths: []int{ 10 }, Trunk: "8" }
+	uzbekistan := Country{ Name: "UZ", Code: "998", Lengths: []int{ 9 } }
+
+	table, err := NewTable(russia, Tajikistan, uzbekistan)
+	if err != nil {
+		t.Errorf("NewTable(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		number	string
+		want	types.Phone
+		country	string
+	} {
+		{ number: "8 912 345-67-89",	want: "+79123456789",	country: "RU" },
+		{ number: "912 345 67 89",		want: "+79123456789",	country: "RU" },
+		{ number: "+992 93 745 29 45",	want: "+992937452945",	country: "TJ" },
+		{ number: "998901234567",		want: "+998901234567",	country: "UZ" },
+	}
+
+	for _, test := range tests {
+		phone, err := table.Normalize(test.number)
+		if err != nil || phone != test.want {
+			t.Errorf("Normalize(%q): got %v, error = %v", test.number, phone, err)
+			continue
+		}
+
+		country, err := table.Country(phone)
+		if err != nil || country.Name != test.country {
+			t.Errorf("Country(%q): got %v, error = %v", phone, country.Name, err)
+		}
+	}
+
+	_, err = NewTable(Country{ Name: "XX", Code: "1a", Lengths: []int{ 10 } })
+	if !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("NewTable(): must return ErrInvalidPhone, but error = %v", err)
+		return
+	}
+}
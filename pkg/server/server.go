@@ -0,0 +1,602 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/api"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// maxBodySize limits size of request bodies in bytes
+const maxBodySize = 1 << 20
+
+// Server - HTTP JSON API over wallet service
+type Server struct {
+	Addr			string
+	ExportDir		string
+	ShutdownTimeout	time.Duration
+	IdempotencyTTL	time.Duration
+	Locale			money.Locale	// how amounts are formatted in responses, money.English by default
+
+	mu				sync.Mutex
+	svc				*wallet.Service
+	log				wallet.Logger
+	idempotency		map[string]savedResponse
+}
+
+// savedResponse - response remembered for an idempotency key
+type savedResponse struct {
+	fingerprint	string
+	status		int
+	payload		[]byte
+	expires		time.Time
+}
+
+// New creates server for the given service, errors are logged by the logger, nil discards them
+func New(svc *wallet.Service, addr string, logger wallet.Logger) *Server {
+	if logger == nil {
+		logger = wallet.NopLogger{}
+	}
+
+	return &Server {
+		Addr:				addr,
+		ShutdownTimeout:	10 * time.Second,
+		IdempotencyTTL:		24 * time.Hour,
+		Locale:				money.English,
+		svc:				svc,
+		log:				logger,
+	}
+}
+
+// Locker returns lock guarding the service, it must be held by anything
+// using the service while the server is running, e.g. a scheduler
+func (s *Server) Locker() sync.Locker {
+	return &s.mu
+}
+
+// ListenAndServe serves requests until the context is cancelled and shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server {
+		Addr:			s.Addr,
+		Handler:		s,
+		ReadTimeout:	15 * time.Second,
+		WriteTimeout:	15 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <- errs:
+		return err
+	case <- ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <- errs
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// ServeHTTP routes requests to handlers
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) == 0 {
+		s.notFound(w, r)
+		return
+	}
+
+	switch parts[0] {
+	case "accounts":
+		s.routeAccounts(w, r, parts[1:])
+	case "payments":
+		s.routePayments(w, r, parts[1:])
+	case "favorites":
+		s.routeFavorites(w, r, parts[1:])
+	case "export":
+		if len(parts) != 1 {
+			s.notFound(w, r)
+			return
+		}
+		s.handle(w, r, http.MethodPost, s.export)
+	default:
+		s.notFound(w, r)
+	}
+}
+
+func (s *Server) routeAccounts(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		s.handle(w, r, http.MethodPost, s.registerAccount)
+	case len(parts) == 1:
+		s.handle(w, r, http.MethodGet, s.withAccountID(parts[0], s.findAccount))
+	case len(parts) == 2 && parts[1] == "deposit":
+		s.handle(w, r, http.MethodPost, s.withAccountID(parts[0], s.deposit))
+	case len(parts) == 2 && parts[1] == "payments":
+		s.handle(w, r, http.MethodGet, s.withAccountID(parts[0], s.history))
+	default:
+		s.notFound(w, r)
+	}
+}
+
+func (s *Server) routePayments(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		s.handle(w, r, http.MethodPost, s.pay)
+	case len(parts) == 1 && parts[0] == "quote":
+		s.handle(w, r, http.MethodPost, s.quotePayment)
+	case len(parts) == 1:
+		s.handle(w, r, http.MethodGet, s.withID(parts[0], s.findPayment))
+	case len(parts) == 2 && parts[1] == "reject":
+		s.handle(w, r, http.MethodPost, s.withID(parts[0], s.reject))
+	case len(parts) == 2 && parts[1] == "repeat":
+		s.handle(w, r, http.MethodPost, s.withID(parts[0], s.repeat))
+	default:
+		s.notFound(w, r)
+	}
+}
+
+func (s *Server) routeFavorites(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		s.handle(w, r, http.MethodPost, s.favoritePayment)
+	case len(parts) == 1:
+		s.handle(w, r, http.MethodGet, s.withID(parts[0], s.findFavorite))
+	case len(parts) == 2 && parts[1] == "pay":
+		s.handle(w, r, http.MethodPost, s.withID(parts[0], s.payFromFavorite))
+	default:
+		s.notFound(w, r)
+	}
+}
+
+// handlerFunc returns http status and response body or an error
+type handlerFunc func(r *http.Request) (int, interface{}, error)
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, method string, handler handlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		s.writeJSON(w, http.StatusMethodNotAllowed, api.ErrorBody {
+			Error: api.ErrorResponse {
+				Code:		api.CodeMethodNotAllowed,
+				Message:	"Method " + r.Method + " is not allowed",
+			},
+		})
+		return
+	}
+
+	s.mu.Lock()
+	status, payload := s.execute(r, handler)
+	s.mu.Unlock()
+
+	s.write(w, status, payload)
+}
+
+// execute runs handler once per idempotency key, repeated requests get the saved response
+func (s *Server) execute(r *http.Request, handler handlerFunc) (int, []byte) {
+	key := r.Header.Get(api.IdempotencyKeyHeader)
+	if key == "" || r.Method != http.MethodPost {
+		return s.respond(r, handler)
+	}
+
+	fingerprint, err := fingerprintOf(r)
+	if err != nil {
+		return s.errorPayload(err)
+	}
+
+	now := time.Now()
+	s.pruneIdempotency(now)
+
+	if saved, ok := s.idempotency[key]; ok {
+		if saved.fingerprint != fingerprint {
+			return s.errorPayload(api.ErrIdempotencyKeyReused)
+		}
+
+		return saved.status, saved.payload
+	}
+
+	status, payload := s.respond(r, handler)
+	if status < http.StatusInternalServerError {
+		if s.idempotency == nil {
+			s.idempotency = make(map[string]savedResponse)
+		}
+
+		s.idempotency[key] = savedResponse {
+			fingerprint:	fingerprint,
+			status:			status,
+			payload:		payload,
+			expires:		now.Add(s.IdempotencyTTL),
+		}
+	}
+
+	return status, payload
+}
+
+func (s *Server) respond(r *http.Request, handler handlerFunc) (int, []byte) {
+	status, body, err := handler(r)
+	if err != nil {
+		return s.errorPayload(err)
+	}
+
+	if status == http.StatusNoContent {
+		return status, nil
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return s.errorPayload(err)
+	}
+
+	return status, payload
+}
+
+func (s *Server) errorPayload(err error) (int, []byte) {
+	code, status := api.CodeOf(err)
+	message := err.Error()
+	if code == api.CodeInternal {
+		s.log.Log(wallet.LevelError, "internal error", wallet.Err(err))
+		message = "Internal server error"
+	}
+
+	payload, err := json.Marshal(api.ErrorBody {
+		Error: api.ErrorResponse {
+			Code:		code,
+			Message:	message,
+		},
+	})
+	if err != nil {
+		s.log.Log(wallet.LevelError, "can't encode error", wallet.Err(err))
+	}
+
+	return status, payload
+}
+
+func (s *Server) pruneIdempotency(now time.Time) {
+	for key, saved := range s.idempotency {
+		if now.After(saved.expires) {
+			delete(s.idempotency, key)
+		}
+	}
+}
+
+func (s *Server) withAccountID(raw string, next func(r *http.Request, accountID int64) (int, interface{}, error)) handlerFunc {
+	return func(r *http.Request) (int, interface{}, error) {
+		accountID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || accountID <= 0 {
+			return 0, nil, fmt.Errorf("%w: invalid account id %q", api.ErrInvalidRequest, raw)
+		}
+
+		return next(r, accountID)
+	}
+}
+
+func (s *Server) withID(raw string, next func(r *http.Request, id string) (int, interface{}, error)) handlerFunc {
+	return func(r *http.Request) (int, interface{}, error) {
+		if strings.TrimSpace(raw) == "" {
+			return 0, nil, fmt.Errorf("%w: id is required", api.ErrInvalidRequest)
+		}
+
+		return next(r, raw)
+	}
+}
+
+func (s *Server) registerAccount(r *http.Request) (int, interface{}, error) {
+	var req api.RegisterAccountRequest
+	err := decode(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if strings.TrimSpace(string(req.Phone)) == "" {
+		return 0, nil, fmt.Errorf("%w: phone is required", api.ErrInvalidRequest)
+	}
+
+	account, err := s.svc.RegisterAccount(req.Phone)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, s.account(account), nil
+}
+
+func (s *Server) findAccount(r *http.Request, accountID int64) (int, interface{}, error) {
+	account, err := s.svc.FindAccountByID(accountID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, s.account(account), nil
+}
+
+func (s *Server) deposit(r *http.Request, accountID int64) (int, interface{}, error) {
+	var req api.DepositRequest
+	err := decode(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = s.svc.Deposit(accountID, req.Amount)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return s.findAccount(r, accountID)
+}
+
+func (s *Server) history(r *http.Request, accountID int64) (int, interface{}, error) {
+	_, err := s.svc.FindAccountByID(accountID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	payments, err := s.svc.ExportAccountHistory(accountID)
+	if err == wallet.ErrAccountNotFound {
+		payments = []*types.Payment{}
+	} else if err != nil {
+		return 0, nil, err
+	}
+
+	formatted := make([]api.Payment, 0, len(payments))
+	for _, payment := range payments {
+		formatted = append(formatted, s.payment(payment))
+	}
+
+	return http.StatusOK, formatted, nil
+}
+
+func (s *Server) pay(r *http.Request) (int, interface{}, error) {
+	var req api.PayRequest
+	err := decode(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if req.AccountID <= 0 {
+		return 0, nil, fmt.Errorf("%w: accountId is required", api.ErrInvalidRequest)
+	}
+
+	if strings.TrimSpace(string(req.Category)) == "" {
+		return 0, nil, fmt.Errorf("%w: category is required", api.ErrInvalidRequest)
+	}
+
+	payment, err := s.svc.Pay(req.AccountID, req.Amount, req.Category)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, s.payment(payment), nil
+}
+
+func (s *Server) quotePayment(r *http.Request) (int, interface{}, error) {
+	var req api.PayRequest
+	err := decode(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if req.AccountID <= 0 {
+		return 0, nil, fmt.Errorf("%w: accountId is required", api.ErrInvalidRequest)
+	}
+
+	if strings.TrimSpace(string(req.Category)) == "" {
+		return 0, nil, fmt.Errorf("%w: category is required", api.ErrInvalidRequest)
+	}
+
+	quote, err := s.svc.QuotePayment(req.AccountID, req.Amount, req.Category)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, quote, nil
+}
+
+func (s *Server) findPayment(r *http.Request, paymentID string) (int, interface{}, error) {
+	payment, err := s.svc.FindPaymentByID(paymentID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, s.payment(payment), nil
+}
+
+func (s *Server) reject(r *http.Request, paymentID string) (int, interface{}, error) {
+	err := s.svc.Reject(paymentID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return s.findPayment(r, paymentID)
+}
+
+func (s *Server) repeat(r *http.Request, paymentID string) (int, interface{}, error) {
+	payment, err := s.svc.Repeat(paymentID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, s.payment(payment), nil
+}
+
+func (s *Server) favoritePayment(r *http.Request) (int, interface{}, error) {
+	var req api.FavoriteRequest
+	err := decode(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if strings.TrimSpace(req.PaymentID) == "" {
+		return 0, nil, fmt.Errorf("%w: paymentId is required", api.ErrInvalidRequest)
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return 0, nil, fmt.Errorf("%w: name is required", api.ErrInvalidRequest)
+	}
+
+	favorite, err := s.svc.FavoritePayment(req.PaymentID, req.Name)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, s.favorite(favorite), nil
+}
+
+func (s *Server) findFavorite(r *http.Request, favoriteID string) (int, interface{}, error) {
+	favorite, err := s.svc.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, s.favorite(favorite), nil
+}
+
+func (s *Server) payFromFavorite(r *http.Request, favoriteID string) (int, interface{}, error) {
+	payment, err := s.svc.PayFromFavorite(favoriteID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, s.payment(payment), nil
+}
+
+func (s *Server) export(r *http.Request) (int, interface{}, error) {
+	if s.ExportDir == "" {
+		return 0, nil, fmt.Errorf("%w: export directory is not configured", api.ErrInvalidRequest)
+	}
+
+	err := s.svc.Export(s.ExportDir)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// account adds balance formatted by the server locale
+func (s *Server) account(account *types.Account) api.Account {
+	return api.Account {
+		Account:	account,
+		Formatted:	money.Format(account.Balance, wallet.AccountCurrency(account), s.Locale),
+	}
+}
+
+// payment adds amount formatted by the server locale
+func (s *Server) payment(payment *types.Payment) api.Payment {
+	return api.Payment {
+		Payment:	payment,
+		Formatted:	money.Format(payment.Amount, wallet.PaymentCurrency(payment), s.Locale),
+	}
+}
+
+// favorite adds amount formatted by the server locale in currency of the account
+func (s *Server) favorite(favorite *types.Favorite) api.Favorite {
+	currency := wallet.DefaultCurrency
+	account, err := s.svc.FindAccountByID(favorite.AccountID)
+	if err == nil {
+		currency = wallet.AccountCurrency(account)
+	}
+
+	return api.Favorite {
+		Favorite:	favorite,
+		Formatted:	money.Format(favorite.Amount, currency, s.Locale),
+	}
+}
+
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusNotFound, api.ErrorBody {
+		Error: api.ErrorResponse {
+			Code:		api.CodeNotFound,
+			Message:	"Path " + r.URL.Path + " not found",
+		},
+	})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		s.log.Log(wallet.LevelError, "can't encode response", wallet.Err(err))
+	}
+
+	s.write(w, status, payload)
+}
+
+func (s *Server) write(w http.ResponseWriter, status int, payload []byte) {
+	if payload == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err := w.Write(append(payload, '\n'))
+	if err != nil {
+		s.log.Log(wallet.LevelWarn, "can't write response", wallet.Err(err))
+	}
+}
+
+func decode(r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", api.ErrInvalidRequest, err)
+	}
+
+	if decoder.More() {
+		return fmt.Errorf("%w: body must contain a single JSON object", api.ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// fingerprintOf identifies request by method, path and body, the body is restored for handlers
+func fingerprintOf(r *http.Request) (string, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", api.ErrInvalidRequest, err)
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
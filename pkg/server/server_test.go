@@ -0,0 +1,343 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/api"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+func TestServer_RegisterAccount_success(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	rec := do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST /accounts: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	var account types.Account
+	err := json.Unmarshal(rec.Body.Bytes(), &account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.ID != 1 || account.Phone != "+992937452945" {
+		t.Errorf("POST /accounts: wrong account returned = %v", account)
+		return
+	}
+}
+
+func TestServer_RegisterAccount_phoneRegistered(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec := do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+
+	assertError(t, rec, http.StatusConflict, api.CodePhoneRegistered)
+}
+
+func TestServer_RegisterAccount_validation(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	for _, body := range []string{ `{}`, `{"phone":""}`, `{"phone":1}`, `{"phone":"+1","extra":true}`, `not json` } {
+		rec := do(srv, http.MethodPost, "/accounts", body)
+		assertError(t, rec, http.StatusBadRequest, api.CodeInvalidRequest)
+	}
+}
+
+func TestServer_Deposit_success(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec := do(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":100}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /accounts/1/deposit: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	var account api.Account
+	err := json.Unmarshal(rec.Body.Bytes(), &account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Balance != 100 || account.Formatted != "1.00 TJS" {
+		t.Errorf("POST /accounts/1/deposit: wrong balance = %v, formatted = %q", account.Balance, account.Formatted)
+		return
+	}
+}
+
+func TestServer_Deposit_fail(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	rec := do(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":100}`)
+	assertError(t, rec, http.StatusNotFound, api.CodeAccountNotFound)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec = do(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":-1}`)
+	assertError(t, rec, http.StatusBadRequest, api.CodeAmountMustBePositive)
+
+	rec = do(srv, http.MethodPost, "/accounts/abc/deposit", `{"amount":1}`)
+	assertError(t, rec, http.StatusBadRequest, api.CodeInvalidRequest)
+}
+
+func TestServer_Pay_notEnoughBalance(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec := do(srv, http.MethodPost, "/payments", `{"accountId":1,"amount":100,"category":"auto"}`)
+
+	assertError(t, rec, http.StatusUnprocessableEntity, api.CodeNotEnoughBalance)
+}
+
+func TestServer_QuotePayment_success(t *testing.T) {
+	schedule, err := fees.NewSchedule([]fees.Rule{ { Currency: "TJS", Category: "auto", Fixed: 5 } })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	svc, err := wallet.NewService(wallet.WithFees(schedule))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	srv := New(svc, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec := do(srv, http.MethodPost, "/payments/quote", `{"accountId":1,"amount":40,"category":"auto"}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /payments/quote: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	var quote wallet.FeeQuote
+	err = json.Unmarshal(rec.Body.Bytes(), &quote)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if quote.Fee != 5 || quote.Total != 45 {
+		t.Errorf("POST /payments/quote: wrong quote = %+v", quote)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/payments/quote", `{"accountId":2,"amount":40,"category":"auto"}`)
+	assertError(t, rec, http.StatusNotFound, api.CodeAccountNotFound)
+}
+
+func TestServer_Payments_success(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	do(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":100}`)
+
+	rec := do(srv, http.MethodPost, "/payments", `{"accountId":1,"amount":40,"category":"auto"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST /payments: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	var payment types.Payment
+	err := json.Unmarshal(rec.Body.Bytes(), &payment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/payments/" + payment.ID + "/repeat", "")
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST /payments/{id}/repeat: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/payments/" + payment.ID + "/reject", "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /payments/{id}/reject: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	err = json.Unmarshal(rec.Body.Bytes(), &payment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusFail {
+		t.Errorf("POST /payments/{id}/reject: status didn't changed, payment = %v", payment)
+		return
+	}
+
+	rec = do(srv, http.MethodGet, "/accounts/1/payments", "")
+	var payments []types.Payment
+	err = json.Unmarshal(rec.Body.Bytes(), &payments)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(payments) != 2 {
+		t.Errorf("GET /accounts/1/payments: wrong size of history = %v", len(payments))
+		return
+	}
+
+	rec = do(srv, http.MethodGet, "/payments/unknown", "")
+	assertError(t, rec, http.StatusNotFound, api.CodePaymentNotFound)
+}
+
+func TestServer_Favorites_success(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	do(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":100}`)
+	rec := do(srv, http.MethodPost, "/payments", `{"accountId":1,"amount":40,"category":"auto"}`)
+
+	var payment types.Payment
+	err := json.Unmarshal(rec.Body.Bytes(), &payment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/favorites", `{"paymentId":"` + payment.ID + `","name":"auto"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST /favorites: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	var favorite types.Favorite
+	err = json.Unmarshal(rec.Body.Bytes(), &favorite)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/favorites/" + favorite.ID + "/pay", "")
+	if rec.Code != http.StatusCreated {
+		t.Errorf("POST /favorites/{id}/pay: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	rec = do(srv, http.MethodPost, "/favorites/" + favorite.ID + "/pay", "")
+	assertError(t, rec, http.StatusUnprocessableEntity, api.CodeNotEnoughBalance)
+
+	rec = do(srv, http.MethodGet, "/favorites/unknown", "")
+	assertError(t, rec, http.StatusNotFound, api.CodeFavoriteNotFound)
+}
+
+func TestServer_Export_success(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	srv := New(&wallet.Service{}, "", nil)
+	srv.ExportDir = dir
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+	rec := do(srv, http.MethodPost, "/export", "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("POST /export: wrong status = %v, body = %s", rec.Code, rec.Body)
+		return
+	}
+
+	_, err = os.Stat(dir + "/accounts.dump")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+}
+
+func TestServer_Idempotency_success(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	do(srv, http.MethodPost, "/accounts", `{"phone":"+992937452945"}`)
+
+	for i := 0; i < 2; i++ {
+		rec := doWithKey(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":100}`, "key-1")
+		if rec.Code != http.StatusOK {
+			t.Errorf("POST /accounts/1/deposit: wrong status = %v, body = %s", rec.Code, rec.Body)
+			return
+		}
+	}
+
+	account, err := srv.svc.FindAccountByID(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Balance != 100 {
+		t.Errorf("POST /accounts/1/deposit: repeated request must not deposit twice, balance = %v", account.Balance)
+		return
+	}
+
+	rec := doWithKey(srv, http.MethodPost, "/accounts/1/deposit", `{"amount":200}`, "key-1")
+	assertError(t, rec, http.StatusUnprocessableEntity, api.CodeIdempotencyKeyReused)
+}
+
+func TestServer_Routing_fail(t *testing.T) {
+	srv := New(&wallet.Service{}, "", nil)
+
+	rec := do(srv, http.MethodGet, "/unknown", "")
+	assertError(t, rec, http.StatusNotFound, api.CodeNotFound)
+
+	rec = do(srv, http.MethodDelete, "/accounts/1", "")
+	assertError(t, rec, http.StatusMethodNotAllowed, api.CodeMethodNotAllowed)
+}
+
+func do(srv *Server, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func doWithKey(srv *Server, method string, path string, body string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set(api.IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Errorf("wrong status = %v, want %v, body = %s", rec.Code, status, rec.Body)
+		return
+	}
+
+	var body api.ErrorBody
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body.Error.Code != code {
+		t.Errorf("wrong error code = %v, want %v", body.Error.Code, code)
+		return
+	}
+
+	if body.Error.Message == "" {
+		t.Error("error message must not be empty, status = " + strconv.Itoa(status))
+	}
+}
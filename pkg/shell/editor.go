@@ -0,0 +1,189 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInterrupted - the operator pressed Ctrl-C
+var ErrInterrupted = errors.New("Interrupted")
+
+// Completer returns the word under completion and its candidates
+type Completer func(line string) (string, []string)
+
+// Editor - line editor for raw terminals with history and tab completion
+type Editor struct {
+	in			*bufio.Reader
+	out			io.Writer
+	complete	Completer
+	history		[]string
+}
+
+// key codes handled by the editor
+const (
+	keyInterrupt	= 3
+	keyEOF			= 4
+	keyBackspace	= 8
+	keyTab			= 9
+	keyNewLine		= 10
+	keyEnter		= 13
+	keyEscape		= 27
+	keyDelete		= 127
+)
+
+// NewEditor creates editor reading keys from in and echoing to out
+func NewEditor(in io.Reader, out io.Writer, complete Completer) *Editor {
+	return &Editor {
+		in:			bufio.NewReader(in),
+		out:		out,
+		complete:	complete,
+	}
+}
+
+// History returns entered lines, oldest first
+func (e *Editor) History() []string {
+	return e.history
+}
+
+// ReadLine reads line, returns io.EOF on Ctrl-D at empty line and ErrInterrupted on Ctrl-C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+
+	line := []rune{}
+	position := len(e.history)
+	draft := ""
+
+	for {
+		key, _, err := e.in.ReadRune()
+		if err == io.EOF && len(line) > 0 {
+			fmt.Fprint(e.out, "\r\n")
+			return e.accept(string(line)), nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, keyNewLine:
+			fmt.Fprint(e.out, "\r\n")
+			return e.accept(string(line)), nil
+		case keyInterrupt:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyEOF:
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if len(line) > 0 {
+				line = line[:len(line) - 1]
+				fmt.Fprint(e.out, "\b \b")
+			}
+		case keyTab:
+			line = e.completeLine(prompt, line)
+		case keyEscape:
+			direction := e.readArrow()
+			switch {
+			case direction == 'A' && position > 0:
+				if position == len(e.history) {
+					draft = string(line)
+				}
+				position--
+				line = []rune(e.history[position])
+				e.redraw(prompt, line)
+			case direction == 'B' && position < len(e.history):
+				position++
+				if position == len(e.history) {
+					line = []rune(draft)
+				} else {
+					line = []rune(e.history[position])
+				}
+				e.redraw(prompt, line)
+			}
+		default:
+			if key >= ' ' {
+				line = append(line, key)
+				fmt.Fprint(e.out, string(key))
+			}
+		}
+	}
+}
+
+// accept saves line into history unless it is empty or repeats the previous one
+func (e *Editor) accept(line string) string {
+	line = strings.TrimSpace(line)
+	if line != "" && (len(e.history) == 0 || e.history[len(e.history) - 1] != line) {
+		e.history = append(e.history, line)
+	}
+
+	return line
+}
+
+// readArrow reads rest of the escape sequence and returns A, B, C or D for arrows
+func (e *Editor) readArrow() rune {
+	next, _, err := e.in.ReadRune()
+	if err != nil || (next != '[' && next != 'O') {
+		return 0
+	}
+
+	direction, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+
+	return direction
+}
+
+func (e *Editor) completeLine(prompt string, line []rune) []rune {
+	if e.complete == nil {
+		return line
+	}
+
+	word, candidates := e.complete(string(line))
+	if len(candidates) == 0 {
+		return line
+	}
+
+	head := string(line)[:len(string(line)) - len(word)]
+	if len(candidates) == 1 {
+		completed := candidates[0]
+		if !strings.HasSuffix(completed, "=") {
+			completed += " "
+		}
+
+		line = []rune(head + completed)
+		e.redraw(prompt, line)
+		return line
+	}
+
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) {
+		line = []rune(head + prefix)
+		e.redraw(prompt, line)
+		return line
+	}
+
+	fmt.Fprint(e.out, "\r\n" + strings.Join(candidates, "  ") + "\r\n")
+	e.redraw(prompt, line)
+	return line
+}
+
+func (e *Editor) redraw(prompt string, line []rune) {
+	fmt.Fprint(e.out, "\r\033[K" + prompt + string(line))
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix) - 1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,478 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+// ErrExit - the operator asked to leave the shell
+var ErrExit = errors.New("Exit")
+
+// ErrUnknownCommand - the command is not supported
+var ErrUnknownCommand = errors.New("Unknown command, type help to see available commands")
+
+// ErrUsage - the command is used with wrong arguments
+var ErrUsage = errors.New("Wrong usage")
+
+// Shell - interactive commands against in-memory service state
+type Shell struct {
+	svc		*wallet.Service
+	dir		string
+	out		io.Writer
+	Locale	money.Locale	// how amounts are printed, money.English by default
+}
+
+// command describes shell command and its arguments
+type command struct {
+	name	string
+	usage	string
+	help	string
+	run		func(s *Shell, args []string) error
+}
+
+// commands are set in init, help and usage refer to them
+var commands []command
+
+func init() {
+	commands = []command {
+		{ name: "accounts",	usage: "accounts",						help: "list all accounts",							run: (*Shell).accounts },
+		{ name: "find",		usage: "find account|payment|favorite <id>",	help: "show account, payment or favorite",	run: (*Shell).find },
+		{ name: "history",	usage: "history <account id>",			help: "list payments of the account",				run: (*Shell).history },
+		{ name: "sum",		usage: "sum [goroutines]",				help: "sums of all payments per currency",	run: (*Shell).sum },
+		{ name: "filter",	usage: "filter key=value ...",			help: "list payments by category, status, account or amount",	run: (*Shell).filter },
+		{ name: "reject",	usage: "reject <payment id>",			help: "reject payment and refund the account",		run: (*Shell).reject },
+		{ name: "save",		usage: "save [dir]",					help: "export all data to the data directory",		run: (*Shell).save },
+		{ name: "help",		usage: "help",							help: "show this help",								run: (*Shell).help },
+		{ name: "exit",		usage: "exit",							help: "leave the shell",							run: (*Shell).exit },
+	}
+}
+
+// filterKeys - keys supported by the filter command
+var filterKeys = []string{ "account", "amount", "category", "status" }
+
+// New creates shell over the service, dir is used by save
+func New(svc *wallet.Service, dir string, out io.Writer) *Shell {
+	return &Shell {
+		svc:	svc,
+		dir:	dir,
+		out:	out,
+		Locale:	money.English,
+	}
+}
+
+// Run reads commands from the editor until exit or end of input
+func (s *Shell) Run(editor *Editor) error {
+	for {
+		line, err := editor.ReadLine("wallet> ")
+		if err == io.EOF {
+			return nil
+		}
+
+		if err == ErrInterrupted {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = s.Execute(line)
+		if err == ErrExit {
+			return nil
+		}
+
+		if err != nil {
+			fmt.Fprintln(s.out, "error:", err)
+		}
+	}
+}
+
+// Execute runs single command line
+func (s *Shell) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == fields[0] {
+			return cmd.run(s, fields[1:])
+		}
+	}
+
+	return ErrUnknownCommand
+}
+
+// Complete returns the word under completion and its candidates
+func (s *Shell) Complete(line string) (string, []string) {
+	fields := strings.Fields(line)
+	word := ""
+	if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+		word = fields[len(fields) - 1]
+		fields = fields[:len(fields) - 1]
+	}
+
+	var options []string
+	switch {
+	case len(fields) == 0:
+		for _, cmd := range commands {
+			options = append(options, cmd.name)
+		}
+	case fields[0] == "find" && len(fields) == 1:
+		options = []string{ "account", "payment", "favorite" }
+	case fields[0] == "find" && len(fields) == 2:
+		switch fields[1] {
+		case "account":
+			options = s.accountIDs()
+		case "payment":
+			options = s.paymentIDs()
+		case "favorite":
+			options = s.favoriteIDs()
+		}
+	case fields[0] == "history" && len(fields) == 1:
+		options = s.accountIDs()
+	case fields[0] == "reject" && len(fields) == 1:
+		options = s.paymentIDs()
+	case fields[0] == "filter":
+		options = s.filterOptions(word)
+	}
+
+	var candidates []string
+	for _, option := range options {
+		if strings.HasPrefix(option, word) {
+			candidates = append(candidates, option)
+		}
+	}
+
+	sort.Strings(candidates)
+	return word, candidates
+}
+
+func (s *Shell) accounts(args []string) error {
+	for _, account := range s.svc.GetAccounts() {
+		s.printAccount(account)
+	}
+
+	return nil
+}
+
+func (s *Shell) find(args []string) error {
+	if len(args) != 2 {
+		return s.usage("find")
+	}
+
+	switch args[0] {
+	case "account":
+		accountID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return s.usage("find")
+		}
+
+		account, err := s.svc.FindAccountByID(accountID)
+		if err != nil {
+			return err
+		}
+
+		s.printAccount(account)
+	case "payment":
+		payment, err := s.svc.FindPaymentByID(args[1])
+		if err != nil {
+			return err
+		}
+
+		s.printPayment(payment)
+	case "favorite":
+		favorite, err := s.svc.FindFavoriteByID(args[1])
+		if err != nil {
+			return err
+		}
+
+		currency := wallet.DefaultCurrency
+		account, err := s.svc.FindAccountByID(favorite.AccountID)
+		if err == nil {
+			currency = wallet.AccountCurrency(account)
+		}
+
+		fmt.Fprintf(s.out, "%s\t%d\t%s\t%s\t%s\n", favorite.ID, favorite.AccountID, favorite.Name, s.amount(favorite.Amount, currency), favorite.Category)
+	default:
+		return s.usage("find")
+	}
+
+	return nil
+}
+
+func (s *Shell) history(args []string) error {
+	if len(args) != 1 {
+		return s.usage("history")
+	}
+
+	accountID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return s.usage("history")
+	}
+
+	_, err = s.svc.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	payments, err := s.svc.ExportAccountHistory(accountID)
+	if err == wallet.ErrAccountNotFound {
+		fmt.Fprintln(s.out, "no payments")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		s.printPayment(payment)
+	}
+
+	return nil
+}
+
+func (s *Shell) sum(args []string) error {
+	goroutines := 1
+	if len(args) > 1 {
+		return s.usage("sum")
+	}
+
+	if len(args) == 1 {
+		value, err := strconv.Atoi(args[0])
+		if err != nil || value <= 0 {
+			return s.usage("sum")
+		}
+		goroutines = value
+	}
+
+	totals, err := s.svc.SumPaymentsByCurrency(goroutines)
+	if err != nil {
+		return err
+	}
+
+	if len(totals) == 0 {
+		fmt.Fprintln(s.out, 0)
+		return nil
+	}
+
+	for _, amount := range totals.Amounts() {
+		fmt.Fprintln(s.out, amount.Format(s.Locale))
+	}
+
+	return nil
+}
+
+func (s *Shell) filter(args []string) error {
+	if len(args) == 0 {
+		return s.usage("filter")
+	}
+
+	var conditions []func(payment types.Payment) bool
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return s.usage("filter")
+		}
+
+		value := parts[1]
+		switch parts[0] {
+		case "account":
+			accountID, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return s.usage("filter")
+			}
+			conditions = append(conditions, func(payment types.Payment) bool {
+				return payment.AccountID == accountID
+			})
+		case "amount":
+			_, err := money.ParseDecimal(value, money.MaxMinor)
+			if err != nil {
+				return err
+			}
+			conditions = append(conditions, func(payment types.Payment) bool {
+				amount, err := money.Parse(value, wallet.PaymentCurrency(&payment))
+				return err == nil && payment.Amount == amount
+			})
+		case "category":
+			conditions = append(conditions, func(payment types.Payment) bool {
+				return string(payment.Category) == value
+			})
+		case "status":
+			conditions = append(conditions, func(payment types.Payment) bool {
+				return strings.EqualFold(string(payment.Status), value)
+			})
+		default:
+			return s.usage("filter")
+		}
+	}
+
+	payments, err := s.svc.FilterPaymentsByFn(func(payment types.Payment) bool {
+		for _, condition := range conditions {
+			if !condition(payment) {
+				return false
+			}
+		}
+		return true
+	}, 1)
+	if err != nil {
+		return err
+	}
+
+	if len(payments) == 0 {
+		fmt.Fprintln(s.out, "no payments")
+		return nil
+	}
+
+	for i := range payments {
+		s.printPayment(&payments[i])
+	}
+
+	return nil
+}
+
+func (s *Shell) reject(args []string) error {
+	if len(args) != 1 {
+		return s.usage("reject")
+	}
+
+	err := s.svc.Reject(args[0])
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.svc.FindPaymentByID(args[0])
+	if err != nil {
+		return err
+	}
+
+	s.printPayment(payment)
+	return nil
+}
+
+func (s *Shell) save(args []string) error {
+	dir := s.dir
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	if len(args) > 1 || dir == "" {
+		return s.usage("save")
+	}
+
+	err := s.svc.Export(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(s.out, "saved to", dir)
+	return nil
+}
+
+func (s *Shell) help(args []string) error {
+	for _, cmd := range commands {
+		fmt.Fprintf(s.out, "%-36s %s\n", cmd.usage, cmd.help)
+	}
+
+	return nil
+}
+
+func (s *Shell) exit(args []string) error {
+	return ErrExit
+}
+
+func (s *Shell) usage(name string) error {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return fmt.Errorf("%w, usage: %s", ErrUsage, cmd.usage)
+		}
+	}
+
+	return ErrUsage
+}
+
+func (s *Shell) printAccount(account *types.Account) {
+	fmt.Fprintf(s.out, "%d\t%s\t%s\n", account.ID, account.Phone, s.amount(account.Balance, wallet.AccountCurrency(account)))
+}
+
+func (s *Shell) printPayment(payment *types.Payment) {
+	fmt.Fprintf(s.out, "%s\t%d\t%s\t%s\t%s\n", payment.ID, payment.AccountID, s.amount(payment.Amount, wallet.PaymentCurrency(payment)), payment.Category, payment.Status)
+}
+
+// amount formats money by the shell locale
+func (s *Shell) amount(value types.Money, currency types.Currency) string {
+	return money.Format(value, currency, s.Locale)
+}
+
+func (s *Shell) accountIDs() []string {
+	var ids []string
+	for _, account := range s.svc.GetAccounts() {
+		ids = append(ids, strconv.FormatInt(account.ID, 10))
+	}
+
+	return ids
+}
+
+func (s *Shell) paymentIDs() []string {
+	var ids []string
+	for _, payment := range s.svc.GetPayments() {
+		ids = append(ids, payment.ID)
+	}
+
+	return ids
+}
+
+func (s *Shell) favoriteIDs() []string {
+	var ids []string
+	for _, favorite := range s.svc.GetFavorites() {
+		ids = append(ids, favorite.ID)
+	}
+
+	return ids
+}
+
+func (s *Shell) filterOptions(word string) []string {
+	parts := strings.SplitN(word, "=", 2)
+	if len(parts) == 1 {
+		var options []string
+		for _, key := range filterKeys {
+			options = append(options, key + "=")
+		}
+		return options
+	}
+
+	var values []string
+	switch parts[0] {
+	case "account":
+		values = s.accountIDs()
+	case "category":
+		seen := make(map[string]bool)
+		for _, payment := range s.svc.GetPayments() {
+			if !seen[string(payment.Category)] {
+				seen[string(payment.Category)] = true
+				values = append(values, string(payment.Category))
+			}
+		}
+	case "status":
+		values = []string {
+			string(types.PaymentStatusOk),
+			string(types.PaymentStatusFail),
+			string(types.PaymentStatusInProgress),
+		}
+	}
+
+	var options []string
+	for _, value := range values {
+		options = append(options, parts[0] + "=" + value)
+	}
+
+	return options
+}
@@ -0,0 +1,261 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/wallet"
+)
+
+func TestShell_Execute_success(t *testing.T) {
+	svc, payment := newTestService(t)
+	out := &bytes.Buffer{}
+	sh := New(svc, "", out)
+
+	for _, line := range []string{ "find account 1", "history 1", "sum", "sum 2", "filter category=auto status=inprogress", "accounts", "help", "" } {
+		err := sh.Execute(line)
+		if err != nil {
+			t.Errorf("Execute(%q): error = %v", line, err)
+			return
+		}
+	}
+
+	err := sh.Execute("reject " + payment.ID)
+	if err != nil {
+		t.Errorf("Execute(reject): error = %v", err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusFail {
+		t.Errorf("Execute(reject): status didn't changed, payment = %v", payment)
+		return
+	}
+
+	if !strings.Contains(out.String(), payment.ID) {
+		t.Errorf("Execute(): output must contain payment id, output = %s", out)
+		return
+	}
+}
+
+func TestShell_Execute_fail(t *testing.T) {
+	svc, _ := newTestService(t)
+	sh := New(svc, "", ioutil.Discard)
+
+	err := sh.Execute("unknown")
+	if err != ErrUnknownCommand {
+		t.Errorf("Execute(): must return ErrUnknownCommand, but error = %v", err)
+		return
+	}
+
+	for _, line := range []string{ "find account", "find account abc", "history", "filter category", "filter color=red", "sum -1", "save" } {
+		err = sh.Execute(line)
+		if !errors.Is(err, ErrUsage) {
+			t.Errorf("Execute(%q): must return ErrUsage, but error = %v", line, err)
+			return
+		}
+	}
+
+	err = sh.Execute("find account 5")
+	if err != wallet.ErrAccountNotFound {
+		t.Errorf("Execute(): must return ErrAccountNotFound, but error = %v", err)
+		return
+	}
+
+	err = sh.Execute("exit")
+	if err != ErrExit {
+		t.Errorf("Execute(): must return ErrExit, but error = %v", err)
+		return
+	}
+}
+
+func TestShell_Execute_filterAmount(t *testing.T) {
+	svc, payment := newTestService(t)
+	out := &bytes.Buffer{}
+	sh := New(svc, "", out)
+
+	err := sh.Execute("filter amount=0.40")
+	if err != nil {
+		t.Errorf("Execute(filter): error = %v", err)
+		return
+	}
+
+	if !strings.Contains(out.String(), payment.ID) {
+		t.Errorf("Execute(filter): payment must match its decimal amount, output = %s", out)
+		return
+	}
+
+	out.Reset()
+	err = sh.Execute("filter amount=40")
+	if err != nil {
+		t.Errorf("Execute(filter): error = %v", err)
+		return
+	}
+
+	if out.String() != "no payments\n" {
+		t.Errorf("Execute(filter): amount is in units not cents, output = %s", out)
+		return
+	}
+
+	err = sh.Execute("filter amount=0,40")
+	if !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("Execute(filter): must return ErrInvalidAmount, but error = %v", err)
+		return
+	}
+}
+
+func TestShell_Save_success(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	svc, _ := newTestService(t)
+	sh := New(svc, dir, ioutil.Discard)
+
+	err = sh.Execute("save")
+	if err != nil {
+		t.Errorf("Execute(save): error = %v", err)
+		return
+	}
+
+	restored := &wallet.Service{}
+	err = restored.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(restored.GetPayments()) != len(svc.GetPayments()) {
+		t.Errorf("Execute(save): wrong number of payments restored = %v", len(restored.GetPayments()))
+		return
+	}
+}
+
+func TestShell_Complete_success(t *testing.T) {
+	svc, payment := newTestService(t)
+	sh := New(svc, "", ioutil.Discard)
+
+	tests := []struct {
+		line		string
+		word		string
+		candidates	[]string
+	} {
+		{ line: "fi",				word: "fi",			candidates: []string{ "filter", "find" } },
+		{ line: "find a",			word: "a",			candidates: []string{ "account" } },
+		{ line: "find account ",	word: "",			candidates: []string{ "1", "2" } },
+		{ line: "history 2",		word: "2",			candidates: []string{ "2" } },
+		{ line: "reject " + payment.ID[:8],	word: payment.ID[:8],	candidates: []string{ payment.ID } },
+		{ line: "filter a",			word: "a",			candidates: []string{ "account=", "amount=" } },
+		{ line: "filter cat",		word: "cat",		candidates: []string{ "category=" } },
+		{ line: "filter category=a",	word: "category=a",	candidates: []string{ "category=auto" } },
+		{ line: "sum ",				word: "",			candidates: nil },
+	}
+
+	for _, test := range tests {
+		word, candidates := sh.Complete(test.line)
+		if word != test.word || !reflect.DeepEqual(candidates, test.candidates) {
+			t.Errorf("Complete(%q): got %q %v, want %q %v", test.line, word, candidates, test.word, test.candidates)
+		}
+	}
+}
+
+func TestEditor_ReadLine_success(t *testing.T) {
+	complete := func(line string) (string, []string) {
+		return "hi", []string{ "history" }
+	}
+
+	input := "hi\t5\r" + "sum\x7f\x7f\x7f\x7fsum\r" + "\x1b[A\x1b[A\r"
+	editor := NewEditor(strings.NewReader(input), ioutil.Discard, complete)
+
+	for _, want := range []string{ "history 5", "sum", "history 5" } {
+		line, err := editor.ReadLine("> ")
+		if err != nil {
+			t.Errorf("ReadLine(): error = %v", err)
+			return
+		}
+
+		if line != want {
+			t.Errorf("ReadLine(): got %q, want %q", line, want)
+			return
+		}
+	}
+
+	_, err := editor.ReadLine("> ")
+	if err != io.EOF {
+		t.Errorf("ReadLine(): must return io.EOF, but error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(editor.History(), []string{ "history 5", "sum", "history 5" }) {
+		t.Errorf("History(): wrong history = %v", editor.History())
+		return
+	}
+}
+
+func TestEditor_ReadLine_interrupted(t *testing.T) {
+	editor := NewEditor(strings.NewReader("abc\x03\x04"), ioutil.Discard, nil)
+
+	_, err := editor.ReadLine("> ")
+	if err != ErrInterrupted {
+		t.Errorf("ReadLine(): must return ErrInterrupted, but error = %v", err)
+		return
+	}
+
+	_, err = editor.ReadLine("> ")
+	if err != io.EOF {
+		t.Errorf("ReadLine(): must return io.EOF, but error = %v", err)
+		return
+	}
+}
+
+func TestShell_Run_success(t *testing.T) {
+	svc, _ := newTestService(t)
+	out := &bytes.Buffer{}
+	sh := New(svc, "", out)
+
+	err := sh.Run(NewEditor(strings.NewReader("find account 9\rexit\rsum\r"), out, sh.Complete))
+	if err != nil {
+		t.Errorf("Run(): error = %v", err)
+		return
+	}
+
+	if !strings.Contains(out.String(), wallet.ErrAccountNotFound.Error()) {
+		t.Errorf("Run(): errors must be printed, output = %s", out)
+		return
+	}
+}
+
+func newTestService(t *testing.T) (*wallet.Service, *types.Payment) {
+	svc := &wallet.Service{}
+
+	account, err := svc.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.Deposit(account.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment, err := svc.Pay(account.ID, 40, "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return svc, payment
+}
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// ErrOverflow - result of money arithmetic doesn't fit into Money
+var ErrOverflow = errors.New("Money overflow")
+
+// OverflowError - operation on money overflowed, it unwraps to ErrOverflow
+type OverflowError struct {
+	Op	string	// +, - or *
+	A	Money
+	B	int64	// second operand, the factor for *
+}
+
+// Error returns the overflowed operation
+func (e *OverflowError) Error() string {
+	return ErrOverflow.Error() + ": " + strconv.FormatInt(int64(e.A), 10) + " " + e.Op + " " + strconv.FormatInt(e.B, 10)
+}
+
+// Unwrap returns ErrOverflow
+func (e *OverflowError) Unwrap() error {
+	return ErrOverflow
+}
+
+// Add returns m + other or OverflowError
+func (m Money) Add(other Money) (Money, error) {
+	if (other > 0 && m > math.MaxInt64 - other) || (other < 0 && m < math.MinInt64 - other) {
+		return 0, &OverflowError{ Op: "+", A: m, B: int64(other) }
+	}
+
+	return m + other, nil
+}
+
+// Sub returns m - other or OverflowError
+func (m Money) Sub(other Money) (Money, error) {
+	if (other < 0 && m > math.MaxInt64 + other) || (other > 0 && m < math.MinInt64 + other) {
+		return 0, &OverflowError{ Op: "-", A: m, B: int64(other) }
+	}
+
+	return m - other, nil
+}
+
+// Mul returns m * factor or OverflowError
+func (m Money) Mul(factor int64) (Money, error) {
+	if m == 0 || factor == 0 {
+		return 0, nil
+	}
+
+	result := int64(m) * factor
+	if result / factor != int64(m) || (m == math.MinInt64 && factor == -1) || (factor == math.MinInt64 && m == -1) {
+		return 0, &OverflowError{ Op: "*", A: m, B: factor }
+	}
+
+	return Money(result), nil
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoney_Add_overflow(t *testing.T) {
+	tests := []struct {
+		name	string
+		op		func() (Money, error)
+		want	Money
+		fail	bool
+	} {
+		{ name: "add",			op: func() (Money, error) { return Money(5).Add(-7) },							want: -2 },
+		{ name: "add max",		op: func() (Money, error) { return Money(math.MaxInt64).Add(1) },				fail: true },
+		{ name: "add min",		op: func() (Money, error) { return Money(math.MinInt64).Add(-1) },				fail: true },
+		{ name: "sub",			op: func() (Money, error) { return Money(math.MinInt64 + 1).Sub(1) },			want: math.MinInt64 },
+		{ name: "sub min",		op: func() (Money, error) { return Money(math.MinInt64).Sub(1) },				fail: true },
+		{ name: "sub max",		op: func() (Money, error) { return Money(0).Sub(math.MinInt64) },				fail: true },
+		{ name: "mul",			op: func() (Money, error) { return Money(-3).Mul(4) },							want: -12 },
+		{ name: "mul max",		op: func() (Money, error) { return Money(math.MaxInt64 / 2 + 1).Mul(2) },		fail: true },
+		{ name: "mul min",		op: func() (Money, error) { return Money(math.MinInt64).Mul(-1) },				fail: true },
+	}
+
+	for _, test := range tests {
+		got, err := test.op()
+		if test.fail {
+			var overflow *OverflowError
+			if !errors.Is(err, ErrOverflow) || !errors.As(err, &overflow) {
+				t.Errorf("%s: must return OverflowError, but error = %v", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %v, want %v, error = %v", test.name, got, test.want, err)
+		}
+	}
+}
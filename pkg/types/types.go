@@ -1,8 +1,11 @@
 package types
 
-// Money in cents
+// Money in minor units of the currency, like cents or dirams
 type Money int64
 
+// Currency - ISO 4217 currency code
+type Currency string
+
 // PaymentCategory - category of the payment
 type PaymentCategory string
 
@@ -14,32 +17,245 @@ const (
 	PaymentStatusOk			PaymentStatus = "OK"
 	PaymentStatusFail		PaymentStatus = "FAIL"
 	PaymentStatusInProgress PaymentStatus = "INPROGRESS"
+	PaymentStatusVoided		PaymentStatus = "VOIDED"
+	PaymentStatusExpired	PaymentStatus = "EXPIRED"
 )
 
 // Payment info
 type Payment struct {
-	ID			string
-	AccountID	int64
-	Amount		Money
-	Category 	PaymentCategory
-	Status 		PaymentStatus
+	ID			string			`json:"id"`
+	AccountID	int64			`json:"accountId"`
+	Amount		Money			`json:"amount"`
+	Category 	PaymentCategory	`json:"category"`
+	Status 		PaymentStatus	`json:"status"`
+	CreatedAt	int64			`json:"createdAt"`	// unix time in seconds
+	Params		map[string]string	`json:"params,omitempty"`	// parameters of the template payment
+	InitiatedBy	Phone			`json:"initiatedBy,omitempty"`	// member who made the payment, empty for the owner
+	Currency	Currency		`json:"currency,omitempty"`	// currency of the account and Amount, empty means TJS
 }
 
 // Phone number
 type Phone string
 
-// Account info
+// AccountStatus - status of the account
+type AccountStatus string
+
+// Predefined account status values, empty status means active
+const (
+	AccountStatusActive	AccountStatus = "ACTIVE"
+	AccountStatusFrozen	AccountStatus = "FROZEN"	// can't spend, but accepts deposits
+	AccountStatusClosed	AccountStatus = "CLOSED"
+)
+
+// Account info, Balance is the ledger balance and Held is reserved by active holds,
+// both in minor units of Currency
 type Account struct {
-	ID		int64
-	Phone	Phone
-	Balance	Money
+	ID				int64			`json:"id"`
+	Phone			Phone			`json:"phone"`
+	Balance			Money			`json:"balance"`
+	Held			Money			`json:"held"`
+	Status			AccountStatus	`json:"status"`
+	StatusReason	string			`json:"statusReason,omitempty"`
+	StatusChangedAt	int64			`json:"statusChangedAt,omitempty"`	// unix time in seconds
+	CustomerID		int64			`json:"customerId,omitempty"`		// main account of the customer, set for pockets only
+	Pocket			string			`json:"pocket,omitempty"`			// name of the pocket, empty for the main one
+	Currency		Currency		`json:"currency,omitempty"`		// currency of the balance, empty means TJS
+}
+
+// Member - phone allowed to spend from the account of other customer, zero limit means no limit
+type Member struct {
+	ID			string				`json:"id"`
+	AccountID	int64				`json:"accountId"`
+	Phone		Phone				`json:"phone"`
+	PerPayment	Money				`json:"perPayment"`
+	Daily		Money				`json:"daily"`
+	Monthly		Money				`json:"monthly"`
+	Categories	[]PaymentCategory	`json:"categories,omitempty"`	// allowed categories, empty means any
+	AddedAt		int64				`json:"addedAt"`	// unix time in seconds
+}
+
+// PocketMove - money moved between pockets of the same customer
+type PocketMove struct {
+	ID				string	`json:"id"`
+	CustomerID		int64	`json:"customerId"`
+	FromAccountID	int64	`json:"fromAccountId"`
+	ToAccountID		int64	`json:"toAccountId"`
+	Amount			Money	`json:"amount"`
+	CreatedAt		int64	`json:"createdAt"`	// unix time in seconds
+}
+
+// PhoneRecord - previous phone of the account
+type PhoneRecord struct {
+	ID			string	`json:"id"`
+	AccountID	int64	`json:"accountId"`
+	Phone		Phone	`json:"phone"`
+	ChangedAt	int64	`json:"changedAt"`	// unix time in seconds when the phone was replaced
+}
+
+// ExchangeStatus - status of the exchange
+type ExchangeStatus string
+
+// Predefined exchange status values
+const (
+	ExchangeStatusQuoted	ExchangeStatus = "QUOTED"
+	ExchangeStatusExecuted	ExchangeStatus = "EXECUTED"
+	ExchangeStatusExpired	ExchangeStatus = "EXPIRED"
+)
+
+// Exchange - conversion between two accounts of the customer, quoted rate is kept until ExpiresAt
+type Exchange struct {
+	ID				string			`json:"id"`
+	CustomerID		int64			`json:"customerId"`
+	FromAccountID	int64			`json:"fromAccountId"`
+	ToAccountID		int64			`json:"toAccountId"`
+	Amount			Money			`json:"amount"`		// debited from the source account without the fee
+	FromCurrency	Currency		`json:"fromCurrency"`
+	Converted		Money			`json:"converted"`		// credited to the target account
+	ToCurrency		Currency		`json:"toCurrency"`
+	Rate			int64			`json:"rate"`			// millionths of ToCurrency per unit of FromCurrency
+	Fee				Money			`json:"fee"`			// in FromCurrency
+	Status			ExchangeStatus	`json:"status"`
+	CreatedAt		int64			`json:"createdAt"`		// unix time in seconds
+	ExpiresAt		int64			`json:"expiresAt"`		// unix time in seconds
+	ExecutedAt		int64			`json:"executedAt,omitempty"`
+	PaymentID		string			`json:"paymentId,omitempty"`		// payment of the amount
+	FeePaymentID	string			`json:"feePaymentId,omitempty"`	// payment of the fee, empty if there is no fee
+	CreditPaymentID	string			`json:"creditPaymentId,omitempty"`	// payment crediting Converted to the target account
+}
+
+// Available returns balance which can be spent
+func (a *Account) Available() Money {
+	return a.Balance - a.Held
 }
 
 // Favorite payment
 type Favorite struct {
-	ID			string
-	AccountID	int64
-	Name		string
-	Amount		Money
-	Category	PaymentCategory
-}
\ No newline at end of file
+	ID			string			`json:"id"`
+	AccountID	int64			`json:"accountId"`
+	Name		string			`json:"name"`
+	Amount		Money			`json:"amount"`
+	Category	PaymentCategory	`json:"category"`
+	UsageCount	int				`json:"usageCount"`	// payments made from the favorite
+	LastUsed	int64			`json:"lastUsed"`		// unix time in seconds, zero if never used
+	Position	int				`json:"position"`		// order of the favorite in the account list
+}
+
+// SpendingLimit - spending limits of the account, zero means no limit
+type SpendingLimit struct {
+	AccountID	int64						`json:"accountId"`
+	PerPayment	Money						`json:"perPayment"`
+	Daily		Money						`json:"daily"`
+	Weekly		Money						`json:"weekly"`
+	Monthly		Money						`json:"monthly"`
+	Categories	map[PaymentCategory]Money	`json:"categories"`	// monthly limit per category
+}
+
+// HoldStatus - status of the hold
+type HoldStatus string
+
+// Predefined hold status values
+const (
+	HoldStatusActive	HoldStatus = "ACTIVE"
+	HoldStatusCaptured	HoldStatus = "CAPTURED"
+	HoldStatusVoided	HoldStatus = "VOIDED"
+	HoldStatusExpired	HoldStatus = "EXPIRED"
+)
+
+// Hold - funds reserved on the account until they are captured
+type Hold struct {
+	ID			string			`json:"id"`
+	AccountID	int64			`json:"accountId"`
+	Amount		Money			`json:"amount"`
+	Category	PaymentCategory	`json:"category"`
+	Status		HoldStatus		`json:"status"`
+	CreatedAt	int64			`json:"createdAt"`	// unix time in seconds
+	ExpiresAt	int64			`json:"expiresAt"`	// unix time in seconds
+	PaymentID	string			`json:"paymentId"`	// payment which closed the hold
+}
+// Refund - money returned to the account for the part of the payment
+type Refund struct {
+	ID			string	`json:"id"`
+	PaymentID	string	`json:"paymentId"`
+	AccountID	int64	`json:"accountId"`
+	Amount		Money	`json:"amount"`
+	CreatedAt	int64	`json:"createdAt"`	// unix time in seconds
+}
+
+// DisputeStatus - status of the dispute
+type DisputeStatus string
+
+// Predefined dispute status values
+const (
+	DisputeStatusOpened			DisputeStatus = "OPENED"
+	DisputeStatusUnderReview	DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusWon			DisputeStatus = "WON"	// customer keeps the money
+	DisputeStatusLost			DisputeStatus = "LOST"	// provisional credit is reversed
+)
+
+// DisputeEvent - step of the dispute
+type DisputeEvent struct {
+	Status	DisputeStatus	`json:"status"`
+	At		int64			`json:"at"`	// unix time in seconds
+}
+
+// Dispute - payment contested by the customer
+type Dispute struct {
+	ID			string			`json:"id"`
+	PaymentID	string			`json:"paymentId"`
+	AccountID	int64			`json:"accountId"`
+	Amount		Money			`json:"amount"`	// provisionally credited amount
+	Reason		string			`json:"reason"`
+	Status		DisputeStatus	`json:"status"`
+	Events		[]DisputeEvent	`json:"events"`
+}
+
+// Schedule - recurring payment of the favorite
+type Schedule struct {
+	ID			string	`json:"id"`
+	FavoriteID	string	`json:"favoriteId"`
+	Spec		string	`json:"spec"`		// e.g. "monthly 1 09:00" or cron expression
+	NextRun		int64	`json:"nextRun"`	// unix time of the next due run
+	Attempts	int		`json:"attempts"`	// failed attempts of the due run
+	RetryAt		int64	`json:"retryAt"`	// unix time of the next retry, zero if there is none
+}
+
+// ScheduleRunStatus - outcome of the scheduled run
+type ScheduleRunStatus string
+
+// Predefined schedule run status values
+const (
+	ScheduleRunOk		ScheduleRunStatus = "OK"
+	ScheduleRunRetry	ScheduleRunStatus = "RETRY"
+	ScheduleRunFailed	ScheduleRunStatus = "FAILED"
+	ScheduleRunSkipped	ScheduleRunStatus = "SKIPPED"
+)
+
+// ScheduleRun - execution of the schedule
+type ScheduleRun struct {
+	ID			string				`json:"id"`
+	ScheduleID	string				`json:"scheduleId"`
+	DueAt		int64				`json:"dueAt"`	// unix time in seconds
+	At			int64				`json:"at"`		// unix time in seconds
+	Status		ScheduleRunStatus	`json:"status"`
+	PaymentID	string				`json:"paymentId"`
+	Error		string				`json:"error"`
+}
+
+// TemplateField - named parameter of the template, e.g. customer number at the merchant
+type TemplateField struct {
+	Name		string	`json:"name"`
+	Required	bool	`json:"required"`
+	Pattern		string	`json:"pattern"`	// regular expression the whole value must match, empty means any
+}
+
+// Template - payment with parameters supplied on each payment
+type Template struct {
+	ID			string			`json:"id"`
+	AccountID	int64			`json:"accountId"`
+	Name		string			`json:"name"`
+	Category	PaymentCategory	`json:"category"`
+	Amount		Money			`json:"amount"`	// fixed amount, zero means it is given on payment
+	MinAmount	Money			`json:"minAmount"`	// bounds of variable amount, zero means no bound
+	MaxAmount	Money			`json:"maxAmount"`
+	Fields		[]TemplateField	`json:"fields"`
+}
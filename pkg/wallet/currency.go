@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrUnknownCurrency - currency code is not in the ISO 4217 table of the money package
+var ErrUnknownCurrency = money.ErrUnknownCurrency
+
+// ErrCurrencyMismatch - operation combines money of accounts in different currencies
+var ErrCurrencyMismatch = money.ErrCurrencyMismatch
+
+// ErrOverflow - balance or sum doesn't fit into Money, errors of the operations are types.OverflowError
+var ErrOverflow = types.ErrOverflow
+
+// currencyOf returns currency of the account, default currency if it is not found
+func (s *Service) currencyOf(accountID int64) types.Currency {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return DefaultCurrency
+	}
+
+	return AccountCurrency(account)
+}
+
+// AccountCurrency returns currency of the account, accounts of old dumps are in TJS.
+// Balance, holds, spending limits and payments of the account are amounts in this
+// currency: operations on one account never convert, moves between accounts refuse
+// other currencies with ErrCurrencyMismatch and only exchanges convert by the rates
+func AccountCurrency(account *types.Account) types.Currency {
+	if account.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return account.Currency
+}
+
+// PaymentCurrency returns currency of the payment, payments of old dumps are in TJS
+func PaymentCurrency(payment *types.Payment) types.Currency {
+	if payment.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return payment.Currency
+}
+
+// sumsOf sums payments per currency in wide accumulators, they are checked for overflow by Totals,
+// released holds moved no money and credits of exchanges and transfers were not paid, they are skipped
+func sumsOf(payments []*types.Payment) money.Sums {
+	sums := money.Sums{}
+	for _, payment := range payments {
+		if released(payment) || exchangeCredit(payment) || transferCredit(payment) {
+			continue
+		}
+
+		sums.Add(money.New(payment.Amount, PaymentCurrency(payment)))
+	}
+
+	return sums
+}
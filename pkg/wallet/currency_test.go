@@ -0,0 +1,244 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addPocketWithBalance(accountID int64, name string, currency types.Currency, balance types.Money) (*types.Account, error) {
+	pocket, err := s.OpenPocketInCurrency(accountID, name, currency)
+	if err != nil {
+		return nil, fmt.Errorf("can't open pocket, error = %v", err)
+	}
+
+	err = s.Deposit(pocket.ID, balance)
+	if err != nil {
+		return nil, fmt.Errorf("can't deposit pocket, error = %v", err)
+	}
+
+	return pocket, nil
+}
+
+func TestService_OpenPocketInCurrency_fail(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(main.ID, "dollars", "USD", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.OpenPocketInCurrency(main.ID, "coins", "XYZ")
+	if !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("OpenPocketInCurrency(): must return ErrUnknownCurrency, but error = %v", err)
+		return
+	}
+
+	if main.Currency != DefaultCurrency || dollars.Currency != "USD" {
+		t.Errorf("OpenPocketInCurrency(): wrong currencies = %v, %v", main.Currency, dollars.Currency)
+		return
+	}
+
+	_, err = s.MoveBetweenPockets(main.ID, dollars.ID, 100)
+	if err != ErrCurrencyMismatch {
+		t.Errorf("MoveBetweenPockets(): must return ErrCurrencyMismatch, but error = %v", err)
+		return
+	}
+
+	_, err = s.TopUp(dollars.ID, "+992937452946", 1_000)
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("TopUp(): must return ErrCurrencyMismatch, but error = %v", err)
+		return
+	}
+
+	balance, err := s.FindCustomerBalance(dollars.ID)
+	if err != nil {
+		t.Errorf("FindCustomerBalance(): error = %v", err)
+		return
+	}
+
+	if balance.Balance != 1_000 || !reflect.DeepEqual(balance.Balances, money.Totals{ "TJS": 1_000, "USD": 1_000 }) {
+		t.Errorf("FindCustomerBalance(): wrong balance = %+v", balance)
+		return
+	}
+}
+
+func TestService_SumPaymentsByCurrency_success(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(main.ID, "dollars", "USD", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, account := range []*types.Account{ main, dollars, dollars } {
+		payment, err := s.Pay(account.ID, 100, "auto")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if payment.Currency != account.Currency {
+			t.Errorf("Pay(): payment currency = %v, want %v", payment.Currency, account.Currency)
+			return
+		}
+	}
+
+	want := money.Totals{ "TJS": 100, "USD": 200 }
+	for _, goroutines := range []int{ 1, 2 } {
+		totals, err := s.SumPaymentsByCurrency(goroutines)
+		if err != nil || !reflect.DeepEqual(totals, want) {
+			t.Errorf("SumPaymentsByCurrency(%d): got %v, want %v", goroutines, totals, want)
+			return
+		}
+	}
+
+	sum, err := s.SumPaymentsChecked(2)
+	if err != nil || sum != 100 {
+		t.Errorf("SumPaymentsChecked(): must sum default currency only, got %v, error = %v", sum, err)
+		return
+	}
+
+	progress := <- s.SumPaymentsWithProgress()
+	if progress.Err != nil || progress.Result != 100 || !reflect.DeepEqual(progress.Totals, want) {
+		t.Errorf("SumPaymentsWithProgress(): wrong progress = %+v", progress)
+		return
+	}
+}
+
+func TestService_Export_currency(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(main.ID, "dollars", "USD", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(dollars.ID, 100, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	account, err := imported.FindAccountByID(dollars.ID)
+	if err != nil || *account != *dollars {
+		t.Errorf("importFrom(): got %+v, want %+v, error = %v", account, dollars, err)
+		return
+	}
+
+	restored, err := imported.FindPaymentByID(payment.ID)
+	if err != nil || !reflect.DeepEqual(restored, payment) {
+		t.Errorf("importFrom(): got %+v, want %+v, error = %v", restored, payment, err)
+		return
+	}
+
+	usd := &types.Account{ ID: main.ID, Phone: main.Phone, Status: types.AccountStatusActive, Currency: "USD" }
+	parsed := s.parseStringToAccounts(s.parseAccountToString(usd, "|"), "|")[0]
+	if *parsed != *usd {
+		t.Errorf("parseStringToAccounts(): got %+v, want %+v", parsed, usd)
+		return
+	}
+}
+
+func TestService_Deposit_overflow(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(main.ID, math.MaxInt64)
+	var overflow *types.OverflowError
+	if !errors.Is(err, ErrOverflow) || !errors.As(err, &overflow) {
+		t.Errorf("Deposit(): must return OverflowError, but error = %v", err)
+		return
+	}
+
+	if main.Balance != 1_000 {
+		t.Errorf("Deposit(): balance must not change, got %v", main.Balance)
+		return
+	}
+}
+
+func TestService_SumPayments_overflow(t *testing.T) {
+	logger := &testLogger{}
+	s := newTestService()
+	s.log = logger
+	s.payments = []*types.Payment {
+		{ Amount: math.MaxInt64, Category: "auto" },
+		{ Amount: math.MaxInt64, Category: "auto" },
+		{ Amount: -math.MaxInt64, Category: "auto" },
+	}
+
+	sum, err := s.SumPaymentsChecked(1)
+	if err != nil || sum != math.MaxInt64 {
+		t.Errorf("SumPaymentsChecked(): wide sum must fit, got %v, error = %v", sum, err)
+		return
+	}
+
+	s.payments = s.payments[:2]
+	for _, goroutines := range []int{ 1, 2 } {
+		_, err = s.SumPaymentsChecked(goroutines)
+		if !errors.Is(err, ErrOverflow) {
+			t.Errorf("SumPaymentsChecked(%d): must return ErrOverflow, but error = %v", goroutines, err)
+			return
+		}
+
+		if sum := s.SumPayments(goroutines); sum != 0 {
+			t.Errorf("SumPayments(%d): overflowed sum must be zero, got %v", goroutines, sum)
+			return
+		}
+	}
+
+	if len(logger.records) != 2 || logger.records[0].level != LevelError {
+		t.Errorf("SumPayments(): overflow must be logged, records = %+v", logger.records)
+		return
+	}
+
+	progress := <- s.SumPaymentsWithProgress()
+	if !errors.Is(progress.Err, ErrOverflow) {
+		t.Errorf("SumPaymentsWithProgress(): must return ErrOverflow, but error = %v", progress.Err)
+		return
+	}
+}
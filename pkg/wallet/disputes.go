@@ -0,0 +1,243 @@
+package wallet
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrDisputeNotFound - dispute does not exist
+var ErrDisputeNotFound = errors.New("Dispute not found")
+
+// ErrPaymentDisputed - payment has a dispute which is not resolved yet, or is disputed the second time
+var ErrPaymentDisputed = errors.New("Payment is disputed")
+
+// ErrInvalidDisputeTransition - dispute can't move to the requested status
+var ErrInvalidDisputeTransition = errors.New("Invalid dispute status transition")
+
+// OpenDispute contests the payment, its net amount is provisionally credited to the account,
+// a payment is disputed only once
+func (s *Service) OpenDispute(paymentID string, reason string) (*types.Dispute, error) {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.everDisputed(paymentID) {
+		return nil, ErrPaymentDisputed
+	}
+
+	amount := payment.Amount - s.refunded(paymentID)
+	if !charged(payment.Status) || exchanged(payment) || transferred(payment) || isFee(payment) || amount <= 0 {
+		return nil, ErrPaymentNotRefundable
+	}
+
+	account, err := s.findOpenAccount(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := account.Balance.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute := &types.Dispute {
+		ID:			s.newID(),
+		PaymentID:	paymentID,
+		AccountID:	account.ID,
+		Amount:		amount,
+		Reason:		reason,
+	}
+	s.moveDispute(dispute, types.DisputeStatusOpened)
+
+	account.Balance = balance
+	s.disputes = append(s.disputes, dispute)
+	s.logger().Log(LevelInfo, "dispute opened", Op("dispute"), AccountID(account.ID), PaymentID(paymentID))
+
+	return dispute, nil
+}
+
+// ReviewDispute moves opened dispute under review
+func (s *Service) ReviewDispute(disputeID string) error {
+	dispute, err := s.FindDisputeByID(disputeID)
+	if err != nil {
+		return err
+	}
+
+	if dispute.Status != types.DisputeStatusOpened {
+		return ErrInvalidDisputeTransition
+	}
+
+	s.moveDispute(dispute, types.DisputeStatusUnderReview)
+	return nil
+}
+
+// ResolveDispute closes the dispute as won or lost, won dispute finalizes the credit
+// as a refund of the payment and lost dispute takes the credit back, even if the
+// balance goes below zero
+func (s *Service) ResolveDispute(disputeID string, status types.DisputeStatus) error {
+	dispute, err := s.FindDisputeByID(disputeID)
+	if err != nil {
+		return err
+	}
+
+	if !disputeActive(dispute) || (status != types.DisputeStatusWon && status != types.DisputeStatusLost) {
+		return ErrInvalidDisputeTransition
+	}
+
+	account, err := s.FindAccountByID(dispute.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if status == types.DisputeStatusWon {
+		s.refunds = append(s.refunds, &types.Refund {
+			ID:			s.newID(),
+			PaymentID:	dispute.PaymentID,
+			AccountID:	dispute.AccountID,
+			Amount:		dispute.Amount,
+			CreatedAt:	s.now().Unix(),
+		})
+	} else {
+		balance, err := account.Balance.Sub(dispute.Amount)
+		if err != nil {
+			return err
+		}
+
+		account.Balance = balance
+	}
+
+	s.moveDispute(dispute, status)
+	s.logger().Log(LevelInfo, "dispute resolved", Op("dispute"), AccountID(account.ID), PaymentID(dispute.PaymentID), Field{ Key: "status", Value: status })
+
+	return nil
+}
+
+// FindDisputeByID returns dispute by id
+func (s *Service) FindDisputeByID(disputeID string) (*types.Dispute, error) {
+	for _, dispute := range s.disputes {
+		if dispute.ID == disputeID {
+			return dispute, nil
+		}
+	}
+
+	return nil, ErrDisputeNotFound
+}
+
+// FindDisputesByAccountID returns disputes of the account
+func (s *Service) FindDisputesByAccountID(accountID int64) []*types.Dispute {
+	var disputes []*types.Dispute
+	for _, dispute := range s.disputes {
+		if dispute.AccountID == accountID {
+			disputes = append(disputes, dispute)
+		}
+	}
+
+	return disputes
+}
+
+// GetDisputes returns disputes
+func (s *Service) GetDisputes() []*types.Dispute {
+	return s.disputes
+}
+
+// disputed checks if the payment has dispute which is not resolved
+func (s *Service) disputed(paymentID string) bool {
+	for _, dispute := range s.disputes {
+		if dispute.PaymentID == paymentID && disputeActive(dispute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// everDisputed checks if the payment has dispute in any status, resolved ones included
+func (s *Service) everDisputed(paymentID string) bool {
+	for _, dispute := range s.disputes {
+		if dispute.PaymentID == paymentID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) moveDispute(dispute *types.Dispute, status types.DisputeStatus) {
+	dispute.Status = status
+	dispute.Events = append(dispute.Events, types.DisputeEvent{ Status: status, At: s.now().Unix() })
+}
+
+func disputeActive(dispute *types.Dispute) bool {
+	return dispute.Status == types.DisputeStatusOpened || dispute.Status == types.DisputeStatusUnderReview
+}
+
+func (s *Service) parseDisputeToString(dispute *types.Dispute) string {
+	events := make([]string, 0, len(dispute.Events))
+	for _, event := range dispute.Events {
+		events = append(events, string(event.Status) + "@" + strconv.FormatInt(event.At, 10))
+	}
+
+	parsed := dispute.ID + ";"
+	parsed += dispute.PaymentID + ";"
+	parsed += strconv.FormatInt(dispute.AccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(dispute.Amount), 10) + ";"
+	parsed += url.QueryEscape(dispute.Reason) + ";"
+	parsed += string(dispute.Status) + ";"
+	parsed += strings.Join(events, ",") + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToDisputes(data string) []*types.Dispute {
+	var disputes []*types.Dispute
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 7 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[2], 10, 64)
+		amount, _		:= strconv.ParseInt(item[3], 10, 64)
+		reason, _		:= url.QueryUnescape(item[4])
+
+		dispute := &types.Dispute {
+			ID:			item[0],
+			PaymentID:	item[1],
+			AccountID:	accountID,
+			Amount:		types.Money(amount),
+			Reason:		reason,
+			Status:		types.DisputeStatus(item[5]),
+		}
+
+		for _, pair := range strings.Split(item[6], ",") {
+			parts := strings.SplitN(pair, "@", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			at, _ := strconv.ParseInt(parts[1], 10, 64)
+			dispute.Events = append(dispute.Events, types.DisputeEvent{ Status: types.DisputeStatus(parts[0]), At: at })
+		}
+
+		disputes = append(disputes, dispute)
+	}
+
+	return disputes
+}
+
+func (s *Service) containsDispute(item *types.Dispute, items []*types.Dispute) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
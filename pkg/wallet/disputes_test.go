@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_OpenDispute_success(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 400, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 100)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	dispute, err := s.OpenDispute(payment.ID, "item not received; seller is silent")
+	if err != nil {
+		t.Errorf("OpenDispute(): error = %v", err)
+		return
+	}
+
+	if dispute.Amount != 300 || dispute.Status != types.DisputeStatusOpened || account.Balance != 1_000 {
+		t.Errorf("OpenDispute(): wrong dispute = %+v, balance = %v", dispute, account.Balance)
+		return
+	}
+
+	_, err = s.OpenDispute(payment.ID, "again")
+	if err != ErrPaymentDisputed {
+		t.Errorf("OpenDispute(): must return ErrPaymentDisputed, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 100)
+	if err != ErrPaymentDisputed {
+		t.Errorf("Refund(): must return ErrPaymentDisputed, but error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentDisputed {
+		t.Errorf("Reject(): must return ErrPaymentDisputed, but error = %v", err)
+		return
+	}
+
+	clock.Advance(time.Hour)
+	err = s.ReviewDispute(dispute.ID)
+	if err != nil {
+		t.Errorf("ReviewDispute(): error = %v", err)
+		return
+	}
+
+	err = s.ReviewDispute(dispute.ID)
+	if err != ErrInvalidDisputeTransition {
+		t.Errorf("ReviewDispute(): must return ErrInvalidDisputeTransition, but error = %v", err)
+		return
+	}
+
+	clock.Advance(time.Hour)
+	err = s.ResolveDispute(dispute.ID, types.DisputeStatusWon)
+	if err != nil {
+		t.Errorf("ResolveDispute(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 1_000 || len(dispute.Events) != 3 || dispute.Events[2].At - dispute.Events[0].At != 7200 {
+		t.Errorf("ResolveDispute(): wrong dispute = %+v, balance = %v", dispute, account.Balance)
+		return
+	}
+
+	history, err := s.FindRefunds(payment.ID)
+	if err != nil || history.Net != 0 {
+		t.Errorf("ResolveDispute(): won dispute must refund the payment, history = %+v, error = %v", history, err)
+		return
+	}
+}
+
+func TestService_ResolveDispute_lost(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 400, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	dispute, err := s.OpenDispute(payment.ID, "")
+	if err != nil {
+		t.Errorf("OpenDispute(): error = %v", err)
+		return
+	}
+
+	err = s.ResolveDispute(dispute.ID, types.DisputeStatusUnderReview)
+	if err != ErrInvalidDisputeTransition {
+		t.Errorf("ResolveDispute(): must return ErrInvalidDisputeTransition, but error = %v", err)
+		return
+	}
+
+	err = s.ResolveDispute(dispute.ID, types.DisputeStatusLost)
+	if err != nil {
+		t.Errorf("ResolveDispute(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 600 {
+		t.Errorf("ResolveDispute(): credit must be reversed, balance = %v", account.Balance)
+		return
+	}
+
+	err = s.ResolveDispute(dispute.ID, types.DisputeStatusWon)
+	if err != ErrInvalidDisputeTransition {
+		t.Errorf("ResolveDispute(): must return ErrInvalidDisputeTransition, but error = %v", err)
+		return
+	}
+
+	_, err = s.OpenDispute(payment.ID, "again")
+	if err != ErrPaymentDisputed || account.Balance != 600 {
+		t.Errorf("OpenDispute(): lost dispute must not be reopened, balance = %v, error = %v", account.Balance, err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentNotRejectable || account.Balance != 600 {
+		t.Errorf("Reject(): lost dispute must not be rejected, balance = %v, error = %v", account.Balance, err)
+		return
+	}
+}
+
+func TestService_FindDisputesByAccountID_export(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 400, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	dispute, err := s.OpenDispute(payment.ID, "double charge; see receipt")
+	if err != nil {
+		t.Errorf("OpenDispute(): error = %v", err)
+		return
+	}
+
+	err = s.ReviewDispute(dispute.ID)
+	if err != nil {
+		t.Errorf("ReviewDispute(): error = %v", err)
+		return
+	}
+
+	if len(s.FindDisputesByAccountID(account.ID)) != 1 || len(s.FindDisputesByAccountID(account.ID + 1)) != 0 {
+		t.Errorf("FindDisputesByAccountID(): wrong disputes = %v", s.GetDisputes())
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	restored, err := imported.FindDisputeByID(dispute.ID)
+	if err != nil {
+		t.Errorf("FindDisputeByID(): error = %v", err)
+		return
+	}
+
+	if restored.Reason != dispute.Reason || restored.Status != types.DisputeStatusUnderReview || len(restored.Events) != 2 {
+		t.Errorf("importFrom(): wrong dispute = %+v", restored)
+		return
+	}
+
+	_, err = imported.FindDisputeByID("unknown")
+	if err != ErrDisputeNotFound {
+		t.Errorf("FindDisputeByID(): must return ErrDisputeNotFound, but error = %v", err)
+		return
+	}
+}
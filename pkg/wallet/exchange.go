@@ -0,0 +1,370 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/exchange"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrRateNotFound - no rate between currencies of the accounts
+var ErrRateNotFound = exchange.ErrRateNotFound
+
+// ErrExchangeNotFound - exchange with the given id doesn't exist
+var ErrExchangeNotFound = errors.New("Exchange not found")
+
+// ErrQuoteExpired - quoted rate is no longer valid
+var ErrQuoteExpired = errors.New("Exchange quote expired")
+
+// ErrQuoteNotQuoted - exchange was already executed or expired
+var ErrQuoteNotQuoted = errors.New("Exchange is not quoted")
+
+// ErrSameCurrency - accounts are in the same currency, money is moved without exchange
+var ErrSameCurrency = errors.New("Accounts are in the same currency")
+
+// ErrExchangeTooSmall - amount converts to zero minor units
+var ErrExchangeTooSmall = errors.New("Amount is too small to exchange")
+
+// Categories of payments made by exchanges, credits are recorded on the target account
+const (
+	ExchangeCategory		types.PaymentCategory = "exchange"
+	ExchangeCreditCategory	types.PaymentCategory = "exchange_credit"
+)
+
+// DefaultQuoteTTL - time during which quoted rate may be executed
+const DefaultQuoteTTL = 30 * time.Second
+
+// QuoteExchange quotes conversion of amount from one account of the customer to another,
+// fee of ExchangeCategory in the schedule is charged on top of the amount in the source currency
+func (s *Service) QuoteExchange(fromAccountID int64, toAccountID int64, amount types.Money) (*types.Exchange, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	from, to, err := s.exchangeAccounts(fromAccountID, toAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromCurrency := AccountCurrency(from)
+	toCurrency := AccountCurrency(to)
+	if fromCurrency == toCurrency {
+		return nil, ErrSameCurrency
+	}
+
+	if s.rates == nil {
+		return nil, ErrRateNotFound
+	}
+
+	converted, rate, err := s.rates.Convert(amount, fromCurrency, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	if converted == 0 {
+		return nil, ErrExchangeTooSmall
+	}
+
+	fee, err := s.feeFor(fromCurrency, ExchangeCategory, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	quote := &types.Exchange {
+		ID:				s.newID(),
+		CustomerID:		customerOf(from),
+		FromAccountID:	fromAccountID,
+		ToAccountID:	toAccountID,
+		Amount:			amount,
+		FromCurrency:	fromCurrency,
+		Converted:		converted,
+		ToCurrency:		toCurrency,
+		Rate:			rate,
+		Fee:			fee,
+		Status:			types.ExchangeStatusQuoted,
+		CreatedAt:		now.Unix(),
+		ExpiresAt:		now.Add(s.quoteTTL()).Unix(),
+	}
+
+	s.exchanges = append(s.exchanges, quote)
+	s.logger().Log(LevelInfo, "exchange quoted", Op("exchange"), AccountID(fromAccountID), Field{ Key: "exchange", Value: quote.ID })
+
+	return quote, nil
+}
+
+// ExecuteExchange debits the amount and the fee and credits converted amount at the quoted
+// rate, nothing is changed if any check fails. Exchanges are not checked against spending limits.
+func (s *Service) ExecuteExchange(exchangeID string) (*types.Exchange, error) {
+	quote, err := s.FindExchangeByID(exchangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.Status != types.ExchangeStatusQuoted {
+		return nil, ErrQuoteNotQuoted
+	}
+
+	now := s.now()
+	if now.Unix() >= quote.ExpiresAt {
+		quote.Status = types.ExchangeStatusExpired
+		return nil, ErrQuoteExpired
+	}
+
+	from, to, err := s.exchangeAccounts(quote.FromAccountID, quote.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if AccountCurrency(from) != quote.FromCurrency || AccountCurrency(to) != quote.ToCurrency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	total, err := quote.Amount.Add(quote.Fee)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+	if from.Available() < total {
+		return nil, ErrNotEnoughBalance
+	}
+
+	fromBalance, err := from.Balance.Sub(total)
+	if err != nil {
+		return nil, err
+	}
+
+	toBalance, err := to.Balance.Add(quote.Converted)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string {
+		"exchange":		quote.ID,
+		"to":			strconv.FormatInt(quote.ToAccountID, 10),
+		"converted":	strconv.FormatInt(int64(quote.Converted), 10),
+		"currency":		string(quote.ToCurrency),
+		"rate":			exchange.FormatRate(quote.Rate),
+	}
+
+	payment := s.exchangePayment(quote.FromAccountID, quote.Amount, quote.FromCurrency, ExchangeCategory, params)
+	quote.PaymentID = payment.ID
+
+	if quote.Fee > 0 {
+		fee := s.chargeFee(quote.FromAccountID, payment.ID, quote.Fee, quote.FromCurrency, types.PaymentStatusOk)
+		quote.FeePaymentID = fee.ID
+	}
+
+	credit := s.exchangePayment(quote.ToAccountID, quote.Converted, quote.ToCurrency, ExchangeCreditCategory, map[string]string {
+		"exchange":	quote.ID,
+		"from":		strconv.FormatInt(quote.FromAccountID, 10),
+		"rate":		exchange.FormatRate(quote.Rate),
+	})
+	quote.CreditPaymentID = credit.ID
+
+	from.Balance = fromBalance
+	to.Balance = toBalance
+	quote.Status = types.ExchangeStatusExecuted
+	quote.ExecutedAt = now.Unix()
+	s.logger().Log(LevelInfo, "exchange executed", Op("exchange"), AccountID(quote.FromAccountID), Field{ Key: "exchange", Value: quote.ID })
+
+	return quote, nil
+}
+
+// FindExchangeByID returns quoted or executed exchange
+func (s *Service) FindExchangeByID(exchangeID string) (*types.Exchange, error) {
+	for _, value := range s.exchanges {
+		if value.ID == exchangeID {
+			return value, nil
+		}
+	}
+
+	return nil, ErrExchangeNotFound
+}
+
+// FindExchanges returns exchanges from and to the account
+func (s *Service) FindExchanges(accountID int64) []*types.Exchange {
+	var exchanges []*types.Exchange
+	for _, value := range s.exchanges {
+		if value.FromAccountID == accountID || value.ToAccountID == accountID {
+			exchanges = append(exchanges, value)
+		}
+	}
+
+	return exchanges
+}
+
+// LoadRates replaces exchange rates by the file, see exchange.Load for its format
+func (s *Service) LoadRates(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	table, err := exchange.Load(file)
+	if err != nil {
+		return err
+	}
+
+	s.rates = table
+	s.logger().Log(LevelInfo, "exchange rates loaded", Op("rates"), Path(path))
+
+	return nil
+}
+
+// exchangeAccounts returns accounts of the same customer, the source must be able to spend
+func (s *Service) exchangeAccounts(fromAccountID int64, toAccountID int64) (*types.Account, *types.Account, error) {
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	to, err := s.FindAccountByID(toAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if customerOf(from) != customerOf(to) || fromAccountID == toAccountID {
+		return nil, nil, ErrNotSameCustomer
+	}
+
+	err = s.checkAccount(from, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.checkAccount(to, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return from, to, nil
+}
+
+// exchangePayment records completed payment of the exchange on one of its accounts
+func (s *Service) exchangePayment(accountID int64, amount types.Money, currency types.Currency, category types.PaymentCategory, params map[string]string) *types.Payment {
+	payment := &types.Payment {
+		ID:			s.newID(),
+		AccountID:	accountID,
+		Amount:		amount,
+		Category:	category,
+		Status:		types.PaymentStatusOk,
+		CreatedAt:	s.now().Unix(),
+		Params:		params,
+		Currency:	currency,
+	}
+
+	s.payments = append(s.payments, payment)
+	return payment
+}
+
+// quoteTTL returns configured expiry time of quotes
+func (s *Service) quoteTTL() time.Duration {
+	if s.quoteExpiry <= 0 {
+		return DefaultQuoteTTL
+	}
+
+	return s.quoteExpiry
+}
+
+// exchanged reports whether the payment was made by an exchange, such payments can't be
+// rejected, refunded, disputed or repeated. Params of other payments may come from templates,
+// so the category is checked too
+func exchanged(payment *types.Payment) bool {
+	if payment.Category != ExchangeCategory && payment.Category != ExchangeCreditCategory {
+		return false
+	}
+
+	return payment.Params["exchange"] != ""
+}
+
+// exchangeCredit reports whether the payment credits the target account of an exchange,
+// it is history of the account and not spending
+func exchangeCredit(payment *types.Payment) bool {
+	return exchanged(payment) && payment.Category == ExchangeCreditCategory
+}
+
+func (s *Service) parseExchangeToString(value *types.Exchange) string {
+	parsed := value.ID + ";"
+	parsed += strconv.FormatInt(value.CustomerID, 10) + ";"
+	parsed += strconv.FormatInt(value.FromAccountID, 10) + ";"
+	parsed += strconv.FormatInt(value.ToAccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(value.Amount), 10) + ";"
+	parsed += string(value.FromCurrency) + ";"
+	parsed += strconv.FormatInt(int64(value.Converted), 10) + ";"
+	parsed += string(value.ToCurrency) + ";"
+	parsed += strconv.FormatInt(value.Rate, 10) + ";"
+	parsed += strconv.FormatInt(int64(value.Fee), 10) + ";"
+	parsed += string(value.Status) + ";"
+	parsed += strconv.FormatInt(value.CreatedAt, 10) + ";"
+	parsed += strconv.FormatInt(value.ExpiresAt, 10) + ";"
+	parsed += strconv.FormatInt(value.ExecutedAt, 10) + ";"
+	parsed += value.PaymentID + ";"
+	parsed += value.FeePaymentID + ";"
+	parsed += value.CreditPaymentID + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToExchanges(data string) []*types.Exchange {
+	var exchanges []*types.Exchange
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 17 {
+			continue
+		}
+
+		customerID, _	:= strconv.ParseInt(item[1], 10, 64)
+		fromID, _		:= strconv.ParseInt(item[2], 10, 64)
+		toID, _			:= strconv.ParseInt(item[3], 10, 64)
+		amount, _		:= strconv.ParseInt(item[4], 10, 64)
+		converted, _	:= strconv.ParseInt(item[6], 10, 64)
+		rate, _			:= strconv.ParseInt(item[8], 10, 64)
+		fee, _			:= strconv.ParseInt(item[9], 10, 64)
+		createdAt, _	:= strconv.ParseInt(item[11], 10, 64)
+		expiresAt, _	:= strconv.ParseInt(item[12], 10, 64)
+		executedAt, _	:= strconv.ParseInt(item[13], 10, 64)
+
+		value := &types.Exchange {
+			ID:					item[0],
+			CustomerID:			customerID,
+			FromAccountID:		fromID,
+			ToAccountID:		toID,
+			Amount:				types.Money(amount),
+			FromCurrency:		types.Currency(item[5]),
+			Converted:			types.Money(converted),
+			ToCurrency:			types.Currency(item[7]),
+			Rate:				rate,
+			Fee:				types.Money(fee),
+			Status:				types.ExchangeStatus(item[10]),
+			CreatedAt:			createdAt,
+			ExpiresAt:			expiresAt,
+			ExecutedAt:			executedAt,
+			PaymentID:			item[14],
+			FeePaymentID:		item[15],
+			CreditPaymentID:	item[16],
+		}
+
+		exchanges = append(exchanges, value)
+	}
+
+	return exchanges
+}
+
+func (s *Service) containsExchange(item *types.Exchange, items []*types.Exchange) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,293 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/exchange"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addExchangeAccounts() (*types.Account, *types.Account, error) {
+	rates, err := exchange.Load(strings.NewReader("rate;USD;TJS;10.90;11.05\n"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't load rates, error = %v", err)
+	}
+
+	schedule, err := fees.NewSchedule([]fees.Rule{ { Currency: "USD", Category: ExchangeCategory, Percent: 50 } })
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't create fee schedule, error = %v", err)
+	}
+
+	s.rates = rates
+	s.fees = schedule
+
+	main, err := s.RegisterAccount("+992937452945")
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't register account, error = %v", err)
+	}
+
+	dollars, err := s.addPocketWithBalance(main.ID, "dollars", "USD", 200_00)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return main, dollars, nil
+}
+
+func TestService_ExecuteExchange_success(t *testing.T) {
+	s := newTestService()
+
+	main, dollars, err := s.addExchangeAccounts()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	quote, err := s.QuoteExchange(dollars.ID, main.ID, 100_00)
+	if err != nil {
+		t.Errorf("QuoteExchange(): error = %v", err)
+		return
+	}
+
+	if quote.Converted != 1_090_00 || quote.Fee != 50 || quote.Status != types.ExchangeStatusQuoted {
+		t.Errorf("QuoteExchange(): wrong quote = %+v", quote)
+		return
+	}
+
+	if dollars.Balance != 200_00 || main.Balance != 0 {
+		t.Errorf("QuoteExchange(): balances must not change, got %v, %v", dollars.Balance, main.Balance)
+		return
+	}
+
+	executed, err := s.ExecuteExchange(quote.ID)
+	if err != nil {
+		t.Errorf("ExecuteExchange(): error = %v", err)
+		return
+	}
+
+	if dollars.Balance != 99_50 || main.Balance != 1_090_00 || executed.Status != types.ExchangeStatusExecuted {
+		t.Errorf("ExecuteExchange(): wrong balances = %v, %v", dollars.Balance, main.Balance)
+		return
+	}
+
+	payment, err := s.FindPaymentByID(executed.PaymentID)
+	if err != nil || payment.Amount != 100_00 || payment.Currency != "USD" || payment.Params["exchange"] != quote.ID || payment.Params["rate"] != "10.9" {
+		t.Errorf("ExecuteExchange(): wrong payment = %+v, error = %v", payment, err)
+		return
+	}
+
+	fee, err := s.FindPaymentByID(executed.FeePaymentID)
+	if err != nil || fee.Amount != 50 || fee.Category != FeeCategory || fee.Params["fee"] != payment.ID {
+		t.Errorf("ExecuteExchange(): wrong fee = %+v, error = %v", fee, err)
+		return
+	}
+
+	credit, err := s.FindPaymentByID(executed.CreditPaymentID)
+	if err != nil || credit.AccountID != main.ID || credit.Amount != 1_090_00 || credit.Currency != DefaultCurrency || credit.Category != ExchangeCreditCategory {
+		t.Errorf("ExecuteExchange(): wrong credit = %+v, error = %v", credit, err)
+		return
+	}
+
+	history, err := s.ExportAccountHistory(main.ID)
+	if err != nil || len(history) != 1 || history[0] != credit {
+		t.Errorf("ExportAccountHistory(): target account must have the credit, history = %v, error = %v", history, err)
+		return
+	}
+
+	sum, err := s.SumPaymentsChecked(1)
+	if err != nil || sum != 0 {
+		t.Errorf("SumPaymentsChecked(): credit must not be summed, got %v, error = %v", sum, err)
+		return
+	}
+
+	_, err = s.ExecuteExchange(quote.ID)
+	if err != ErrQuoteNotQuoted {
+		t.Errorf("ExecuteExchange(): must return ErrQuoteNotQuoted, but error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentNotRejectable {
+		t.Errorf("Reject(): must return ErrPaymentNotRejectable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(fee.ID, 10)
+	if err != ErrPaymentNotRefundable {
+		t.Errorf("Refund(): must return ErrPaymentNotRefundable, but error = %v", err)
+		return
+	}
+
+	for _, id := range []string{ payment.ID, fee.ID, credit.ID } {
+		_, err = s.OpenDispute(id, "not mine")
+		if err != ErrPaymentNotRefundable {
+			t.Errorf("OpenDispute(): must return ErrPaymentNotRefundable, but error = %v", err)
+			return
+		}
+
+		_, err = s.Repeat(id)
+		if err != ErrPaymentNotRepeatable {
+			t.Errorf("Repeat(): must return ErrPaymentNotRepeatable, but error = %v", err)
+			return
+		}
+	}
+
+	if dollars.Balance != 99_50 || main.Balance != 1_090_00 || len(s.GetDisputes()) != 0 {
+		t.Errorf("OpenDispute(), Repeat(): balances must not change, got %v, %v", dollars.Balance, main.Balance)
+		return
+	}
+}
+
+func TestService_ExecuteExchange_fail(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	main, dollars, err := s.addExchangeAccounts()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	other, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		from	int64
+		to		int64
+		amount	types.Money
+		want	error
+	} {
+		{ name: "same currency",	from: main.ID,		to: savings.ID,	amount: 100,	want: ErrSameCurrency },
+		{ name: "other customer",	from: dollars.ID,	to: other.ID,	amount: 100,	want: ErrNotSameCustomer },
+		{ name: "too small",		from: main.ID,		to: dollars.ID,	amount: 10,		want: ErrExchangeTooSmall },
+		{ name: "negative",			from: dollars.ID,	to: main.ID,	amount: -1,		want: ErrAmountMustBePositive },
+	}
+
+	for _, test := range tests {
+		_, err := s.QuoteExchange(test.from, test.to, test.amount)
+		if err != test.want {
+			t.Errorf("%s: QuoteExchange(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+
+	quote, err := s.QuoteExchange(dollars.ID, main.ID, 200_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.ExecuteExchange(quote.ID)
+	if err != ErrNotEnoughBalance || dollars.Balance != 200_00 || len(s.payments) != 0 {
+		t.Errorf("ExecuteExchange(): must fail without changes, error = %v", err)
+		return
+	}
+
+	quote, err = s.QuoteExchange(dollars.ID, main.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	clock.Advance(DefaultQuoteTTL)
+	_, err = s.ExecuteExchange(quote.ID)
+	if err != ErrQuoteExpired || quote.Status != types.ExchangeStatusExpired {
+		t.Errorf("ExecuteExchange(): must return ErrQuoteExpired, but error = %v", err)
+		return
+	}
+
+	s.rates = nil
+	_, err = s.QuoteExchange(dollars.ID, main.ID, 100_00)
+	if !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("QuoteExchange(): must return ErrRateNotFound without rates, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Reject_exchangeParams(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 400, "shop")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// params of template payments are set by the customer
+	payment.Params = map[string]string{ "exchange": payment.ID }
+
+	err = s.Reject(payment.ID)
+	if err != nil || account.Balance != 1_000 {
+		t.Errorf("Reject(): payment is not an exchange, balance = %v, error = %v", account.Balance, err)
+		return
+	}
+}
+
+func TestService_Export_exchanges(t *testing.T) {
+	s := newTestService()
+
+	main, dollars, err := s.addExchangeAccounts()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	quote, err := s.QuoteExchange(dollars.ID, main.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.ExecuteExchange(quote.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	exchanges := imported.FindExchanges(main.ID)
+	if len(exchanges) != 1 || !reflect.DeepEqual(exchanges[0], quote) {
+		t.Errorf("importFrom(): got %+v, want %+v", exchanges, quote)
+		return
+	}
+
+	payment, err := imported.FindPaymentByID(quote.PaymentID)
+	if err != nil || payment.Params["converted"] != "109000" || payment.Currency != "USD" {
+		t.Errorf("importFrom(): wrong payment = %+v, error = %v", payment, err)
+		return
+	}
+}
@@ -0,0 +1,186 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrFavoriteNameTaken - account already has favorite with the name
+var ErrFavoriteNameTaken = errors.New("Favorite with the given name already exists")
+
+// ErrInvalidFavoriteName - favorite can't be renamed to an empty name
+var ErrInvalidFavoriteName = errors.New("Favorite name must not be empty")
+
+// ErrInvalidFavoriteOrder - order does not list every favorite of the account exactly once
+var ErrInvalidFavoriteOrder = errors.New("Order must list every favorite of the account once")
+
+// FavoriteSort - order of favorites returned by FindFavoritesByAccountID
+type FavoriteSort int
+
+// Favorite orders
+const (
+	SortByPosition		FavoriteSort = iota	// order set by ReorderFavorites
+	SortByPopularity						// most used first, then most recently used
+)
+
+// FindFavoritesByAccountID returns favorites of the account in the given order
+func (s *Service) FindFavoritesByAccountID(accountID int64, order FavoriteSort) []*types.Favorite {
+	var favorites []*types.Favorite
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID {
+			favorites = append(favorites, favorite)
+		}
+	}
+
+	sort.SliceStable(favorites, func(i, j int) bool {
+		a, b := favorites[i], favorites[j]
+		if order == SortByPopularity && a.UsageCount != b.UsageCount {
+			return a.UsageCount > b.UsageCount
+		}
+
+		if order == SortByPopularity && a.LastUsed != b.LastUsed {
+			return a.LastUsed > b.LastUsed
+		}
+
+		return a.Position < b.Position
+	})
+
+	return favorites
+}
+
+// FindFavoriteByName returns favorite of the account with the name
+func (s *Service) FindFavoriteByName(accountID int64, name string) (*types.Favorite, error) {
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID && favorite.Name == name {
+			return favorite, nil
+		}
+	}
+
+	return nil, ErrFavoriteNotFound
+}
+
+// UpdateFavorite changes name, amount and category of the favorite
+func (s *Service) UpdateFavorite(favoriteID string, name string, amount types.Money, category types.PaymentCategory) (*types.Favorite, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	_, err = s.findOpenAccount(favorite.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrInvalidFavoriteName
+	}
+
+	err = s.checkFavoriteName(favorite.AccountID, favoriteID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	favorite.Name = name
+	favorite.Amount = amount
+	favorite.Category = category
+	s.logger().Log(LevelInfo, "favorite updated", Op("favorite"), AccountID(favorite.AccountID), FavoriteID(favoriteID))
+
+	return favorite, nil
+}
+
+// RenameFavorite changes name of the favorite
+func (s *Service) RenameFavorite(favoriteID string, name string) (*types.Favorite, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpdateFavorite(favoriteID, name, favorite.Amount, favorite.Category)
+}
+
+// DeleteFavorite removes the favorite and its schedules
+func (s *Service) DeleteFavorite(favoriteID string) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.findOpenAccount(favorite.AccountID)
+	if err != nil {
+		return err
+	}
+
+	for i, value := range s.favorites {
+		if value == favorite {
+			s.favorites = append(s.favorites[:i], s.favorites[i + 1:]...)
+			break
+		}
+	}
+
+	var schedules []*types.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.FavoriteID != favoriteID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	s.schedules = schedules
+
+	// keep positions of the rest without gaps
+	for i, value := range s.FindFavoritesByAccountID(favorite.AccountID, SortByPosition) {
+		value.Position = i
+	}
+
+	s.logger().Log(LevelInfo, "favorite deleted", Op("favorite"), AccountID(favorite.AccountID), FavoriteID(favoriteID))
+	return nil
+}
+
+// ReorderFavorites sets order of the account favorites, ids must list each of them once
+func (s *Service) ReorderFavorites(accountID int64, favoriteIDs []string) error {
+	_, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	favorites := s.FindFavoritesByAccountID(accountID, SortByPosition)
+	if len(favorites) != len(favoriteIDs) {
+		return ErrInvalidFavoriteOrder
+	}
+
+	positions := make(map[string]int, len(favoriteIDs))
+	for i, id := range favoriteIDs {
+		positions[id] = i
+	}
+
+	for _, favorite := range favorites {
+		if _, ok := positions[favorite.ID]; !ok {
+			return ErrInvalidFavoriteOrder
+		}
+	}
+
+	for _, favorite := range favorites {
+		favorite.Position = positions[favorite.ID]
+	}
+
+	return nil
+}
+
+// checkFavoriteName checks that no other favorite of the account has the name,
+// favorites saved without a name are not unique
+func (s *Service) checkFavoriteName(accountID int64, favoriteID string, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	saved, err := s.FindFavoriteByName(accountID, name)
+	if err == nil && saved.ID != favoriteID {
+		return ErrFavoriteNameTaken
+	}
+
+	return nil
+}
@@ -0,0 +1,356 @@
+package wallet
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addFavorites(account *types.Account, names ...string) ([]*types.Favorite, error) {
+	var favorites []*types.Favorite
+	for _, name := range names {
+		payment, err := s.Pay(account.ID, 100, types.PaymentCategory(name))
+		if err != nil {
+			return nil, fmt.Errorf("can't make payment, error = %v", err)
+		}
+
+		favorite, err := s.FavoritePayment(payment.ID, name)
+		if err != nil {
+			return nil, fmt.Errorf("can't make favorite, error = %v", err)
+		}
+		favorites = append(favorites, favorite)
+	}
+
+	return favorites, nil
+}
+
+func favoriteNames(favorites []*types.Favorite) string {
+	var names []string
+	for _, favorite := range favorites {
+		names = append(names, favorite.Name)
+	}
+
+	return fmt.Sprint(names)
+}
+
+func TestService_FavoritePayment_uniqueName(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100, "internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "internet")
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("FavoritePayment(): must return ErrFavoriteNameTaken, but error = %v", err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "home; office")
+	if err != nil {
+		t.Errorf("FavoritePayment(): names are escaped in dumps, error = %v", err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = s.FavoritePayment(payment.ID, "")
+		if err != nil {
+			t.Errorf("FavoritePayment(): favorites may be saved without a name, error = %v", err)
+			return
+		}
+	}
+
+	other, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(other.ID, 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err = s.Pay(other.ID, 100, "internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "internet")
+	if err != nil {
+		t.Errorf("FavoritePayment(): names are unique per account, error = %v", err)
+		return
+	}
+
+	found, err := s.FindFavoriteByName(account.ID, "internet")
+	if err != nil || found != favorites[0] {
+		t.Errorf("FindFavoriteByName(): wrong favorite = %v, error = %v", found, err)
+		return
+	}
+}
+
+func TestService_UpdateFavorite_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet", "phone")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	updated, err := s.UpdateFavorite(favorites[0].ID, "home internet", 250, "isp")
+	if err != nil {
+		t.Errorf("UpdateFavorite(): error = %v", err)
+		return
+	}
+
+	if updated.Name != "home internet" || updated.Amount != 250 || updated.Category != "isp" {
+		t.Errorf("UpdateFavorite(): wrong favorite = %+v", updated)
+		return
+	}
+
+	_, err = s.RenameFavorite(favorites[1].ID, "home internet")
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("RenameFavorite(): must return ErrFavoriteNameTaken, but error = %v", err)
+		return
+	}
+
+	_, err = s.RenameFavorite(favorites[0].ID, "home internet")
+	if err != nil {
+		t.Errorf("RenameFavorite(): same name of the same favorite, error = %v", err)
+		return
+	}
+
+	_, err = s.UpdateFavorite(favorites[0].ID, "internet", 0, "isp")
+	if err != ErrAmountMustBePositive {
+		t.Errorf("UpdateFavorite(): must return ErrAmountMustBePositive, but error = %v", err)
+		return
+	}
+
+	_, err = s.UpdateFavorite("unknown", "internet", 100, "isp")
+	if err != ErrFavoriteNotFound {
+		t.Errorf("UpdateFavorite(): must return ErrFavoriteNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_DeleteFavorite_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet", "phone", "gas")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SchedulePayment(favorites[1].ID, "daily 09:00")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.DeleteFavorite(favorites[1].ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+
+	_, err = s.FindFavoriteByID(favorites[1].ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("FindFavoriteByID(): must return ErrFavoriteNotFound, but error = %v", err)
+		return
+	}
+
+	if len(s.GetSchedules()) != 0 {
+		t.Errorf("DeleteFavorite(): schedules of the favorite must be removed, schedules = %v", s.GetSchedules())
+		return
+	}
+
+	listed := s.FindFavoritesByAccountID(account.ID, SortByPosition)
+	if favoriteNames(listed) != "[internet gas]" || listed[1].Position != 1 {
+		t.Errorf("DeleteFavorite(): wrong favorites = %v", favoriteNames(listed))
+		return
+	}
+
+	err = s.DeleteFavorite(favorites[1].ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("DeleteFavorite(): must return ErrFavoriteNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_ReorderFavorites_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet", "phone", "gas")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.ReorderFavorites(account.ID, []string{ favorites[2].ID, favorites[0].ID, favorites[1].ID })
+	if err != nil {
+		t.Errorf("ReorderFavorites(): error = %v", err)
+		return
+	}
+
+	listed := s.FindFavoritesByAccountID(account.ID, SortByPosition)
+	if favoriteNames(listed) != "[gas internet phone]" {
+		t.Errorf("ReorderFavorites(): wrong order = %v", favoriteNames(listed))
+		return
+	}
+
+	err = s.ReorderFavorites(account.ID, []string{ favorites[2].ID, favorites[0].ID, favorites[0].ID })
+	if err != ErrInvalidFavoriteOrder {
+		t.Errorf("ReorderFavorites(): must return ErrInvalidFavoriteOrder, but error = %v", err)
+		return
+	}
+
+	err = s.ReorderFavorites(account.ID, []string{ favorites[2].ID })
+	if err != ErrInvalidFavoriteOrder {
+		t.Errorf("ReorderFavorites(): must return ErrInvalidFavoriteOrder, but error = %v", err)
+		return
+	}
+}
+
+func TestService_FindFavoritesByAccountID_popularity(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet", "phone", "gas")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	uses := []int{ 1, 2, 1 }
+	for i, count := range uses {
+		for j := 0; j < count; j++ {
+			clock.Advance(time.Minute)
+			_, err := s.PayFromFavorite(favorites[i].ID)
+			if err != nil {
+				t.Errorf("PayFromFavorite(): error = %v", err)
+				return
+			}
+		}
+	}
+
+	if favorites[1].UsageCount != 2 || favorites[2].LastUsed != clock.Now().Unix() {
+		t.Errorf("PayFromFavorite(): usage is not tracked, favorite = %+v", favorites[1])
+		return
+	}
+
+	listed := s.FindFavoritesByAccountID(account.ID, SortByPopularity)
+	if favoriteNames(listed) != "[phone gas internet]" {
+		t.Errorf("FindFavoritesByAccountID(): wrong order = %v", favoriteNames(listed))
+		return
+	}
+}
+
+func TestService_Export_favoriteUsage(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorites, err := s.addFavorites(account, "internet", "phone")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayFromFavorite(favorites[1].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.ReorderFavorites(account.ID, []string{ favorites[1].ID, favorites[0].ID })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.RenameFavorite(favorites[1].ID, "mobile; 50%\nhome")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	restored, err := imported.FindFavoriteByID(favorites[1].ID)
+	if err != nil {
+		t.Errorf("FindFavoriteByID(): error = %v", err)
+		return
+	}
+
+	if *restored != *favorites[1] {
+		t.Errorf("importFrom(): got %+v, want %+v", restored, favorites[1])
+		return
+	}
+
+	// dumps written before usage was tracked
+	old := imported.parseStringToFavorites("1;1;auto;10;auto")
+	if len(old) != 1 || old[0].Name != "auto" || old[0].UsageCount != 0 {
+		t.Errorf("parseStringToFavorites(): wrong favorite = %+v", old)
+		return
+	}
+}
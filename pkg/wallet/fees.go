@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"os"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// FeeCategory - category of fee lines charged on top of payments, transfers and exchanges
+const FeeCategory types.PaymentCategory = "fee"
+
+// FeeQuote - preview of the payment, Total is debited from the account
+type FeeQuote struct {
+	AccountID	int64					`json:"accountId"`
+	Category	types.PaymentCategory	`json:"category"`
+	Amount		types.Money				`json:"amount"`
+	Fee			types.Money				`json:"fee"`
+	Total		types.Money				`json:"total"`
+	Currency	types.Currency			`json:"currency"`
+}
+
+// QuotePayment previews fee and total of the payment, nothing is changed
+// and balance and limits are checked only when paying
+func (s *Service) QuotePayment(accountID int64, amount types.Money, category types.PaymentCategory) (*FeeQuote, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := AccountCurrency(account)
+	fee, err := s.feeFor(currency, category, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeQuote {
+		AccountID:	accountID,
+		Category:	category,
+		Amount:		amount,
+		Fee:		fee,
+		Total:		total,
+		Currency:	currency,
+	}, nil
+}
+
+// FindFee returns fee line of the payment, the transfer or the exchange
+func (s *Service) FindFee(id string) (*types.Payment, error) {
+	fee := s.feeOf(id)
+	if fee == nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	return fee, nil
+}
+
+// LoadFees replaces fee schedule by the file, see fees.Load for its format
+func (s *Service) LoadFees(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	schedule, err := fees.Load(file)
+	if err != nil {
+		return err
+	}
+
+	s.fees = schedule
+	s.logger().Log(LevelInfo, "fee schedule loaded", Op("fees"), Path(path))
+
+	return nil
+}
+
+// feeFor returns fee of the payment in the currency by the schedule, payments are free without one
+func (s *Service) feeFor(currency types.Currency, category types.PaymentCategory, amount types.Money) (types.Money, error) {
+	if s.fees == nil {
+		return 0, nil
+	}
+
+	return s.fees.Fee(currency, category, amount)
+}
+
+// chargeFee records fee line of the payment with the id, the balance is already debited
+func (s *Service) chargeFee(accountID int64, id string, fee types.Money, currency types.Currency, status types.PaymentStatus) *types.Payment {
+	payment := &types.Payment {
+		ID:			s.newID(),
+		AccountID:	accountID,
+		Amount:		fee,
+		Category:	FeeCategory,
+		Status:		status,
+		CreatedAt:	s.now().Unix(),
+		Params:		map[string]string{ "fee": id },
+		Currency:	currency,
+	}
+
+	s.payments = append(s.payments, payment)
+	return payment
+}
+
+// feeOf returns fee line charged for the payment with the id, nil if it was free
+func (s *Service) feeOf(id string) *types.Payment {
+	for _, payment := range s.payments {
+		if payment.Category == FeeCategory && payment.Params["fee"] == id {
+			return payment
+		}
+	}
+
+	return nil
+}
+
+// isFee reports whether the payment is a fee line, they follow their payments and
+// can't be rejected, refunded, disputed or repeated alone
+func isFee(payment *types.Payment) bool {
+	return payment.Category == FeeCategory && payment.Params["fee"] != ""
+}
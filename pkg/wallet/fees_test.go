@@ -0,0 +1,348 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addFees() error {
+	schedule, err := fees.Load(strings.NewReader(`
+free;salary
+fee;TJS;auto;0;0;1;1;1.50;0
+fee;TJS;transfer;0;0;0.25;0;0;0
+`))
+	if err != nil {
+		return fmt.Errorf("can't load fee schedule, error = %v", err)
+	}
+
+	s.fees = schedule
+	return nil
+}
+
+func (s *testService) addFeeAccount() (*types.Account, error) {
+	err := s.addFees()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.addAccountWithBalance("+992937452945", 10_000)
+}
+
+func TestService_Pay_fee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addFeeAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	quote, err := s.QuotePayment(account.ID, 5_000, "auto")
+	if err != nil || quote.Fee != 150 || quote.Total != 5_150 {
+		t.Errorf("QuotePayment(): got %+v, error = %v", quote, err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 5_000, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 5_000 || account.Balance != 10_000 - quote.Total {
+		t.Errorf("Pay(): amount = %v, balance = %v", payment.Amount, account.Balance)
+		return
+	}
+
+	fee, err := s.FindFee(payment.ID)
+	if err != nil || fee.Amount != 150 || fee.Category != FeeCategory || fee.Status != payment.Status {
+		t.Errorf("FindFee(): got %+v, error = %v", fee, err)
+		return
+	}
+
+	free, err := s.Pay(account.ID, 1_000, "salary")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.FindFee(free.ID)
+	if !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("FindFee(): free payment must have no fee, but error = %v", err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(account.ID, "dollars", "USD", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	other, err := s.Pay(dollars.ID, 5_000, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.FindFee(other.ID)
+	if !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("FindFee(): fee in TJS must not apply to USD payments, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Pay_notEnoughForFee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addFeeAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 9_900, "auto")
+	if !errors.Is(err, ErrNotEnoughBalance) {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, but error = %v", err)
+		return
+	}
+
+	if account.Balance != 10_000 || len(s.payments) != 0 {
+		t.Errorf("Pay(): nothing must change, balance = %v, payments = %d", account.Balance, len(s.payments))
+		return
+	}
+}
+
+func TestService_Reject_fee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addFeeAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 5_000, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	fee, _ := s.FindFee(payment.ID)
+	err = s.Reject(fee.ID)
+	if !errors.Is(err, ErrPaymentNotRejectable) {
+		t.Errorf("Reject(): fee line must not be rejectable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(fee.ID, 10)
+	if !errors.Is(err, ErrPaymentNotRefundable) {
+		t.Errorf("Refund(): fee line must not be refundable, but error = %v", err)
+		return
+	}
+
+	_, err = s.OpenDispute(fee.ID, "not mine")
+	if !errors.Is(err, ErrPaymentNotRefundable) {
+		t.Errorf("OpenDispute(): fee line must not be disputable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(fee.ID)
+	if !errors.Is(err, ErrPaymentNotRepeatable) {
+		t.Errorf("Repeat(): fee line must not be repeatable, but error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 10_000 || fee.Status != types.PaymentStatusFail {
+		t.Errorf("Reject(): fee must be returned, balance = %v, fee status = %v", account.Balance, fee.Status)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if !errors.Is(err, ErrPaymentNotRejectable) || account.Balance != 10_000 {
+		t.Errorf("Reject(): rejected payment must not be returned twice, balance = %v, error = %v", account.Balance, err)
+		return
+	}
+
+	repeated, err := s.Repeat(payment.ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+
+	fee, err = s.FindFee(repeated.ID)
+	if err != nil || fee.Amount != 150 || account.Balance != 4_850 {
+		t.Errorf("Repeat(): fee must be charged again, fee = %+v, balance = %v, error = %v", fee, account.Balance, err)
+		return
+	}
+}
+
+func TestService_Transfer_fee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addFeeAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	target, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	quote, err := s.QuotePayment(account.ID, 1_000, TransferCategory)
+	if err != nil || quote.Fee != 25 || quote.Total != 1_025 {
+		t.Errorf("QuotePayment(): got %+v, error = %v", quote, err)
+		return
+	}
+
+	payment, err := s.Transfer(account.ID, target.ID, 1_000)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 8_975 || target.Balance != 1_000 || payment.Category != TransferCategory {
+		t.Errorf("Transfer(): balances = %v and %v, payment = %+v", account.Balance, target.Balance, payment)
+		return
+	}
+
+	fee, err := s.FindFee(payment.ID)
+	if err != nil || fee.Amount != 25 || fee.AccountID != account.ID {
+		t.Errorf("FindFee(): got %+v, error = %v", fee, err)
+		return
+	}
+
+	history, err := s.ExportAccountHistory(target.ID)
+	if err != nil || len(history) != 1 || history[0].Category != TransferCreditCategory || history[0].Amount != 1_000 {
+		t.Errorf("ExportAccountHistory(): target must have the credit, history = %v, error = %v", history, err)
+		return
+	}
+
+	sum, err := s.SumPaymentsChecked(1)
+	if err != nil || sum != 1_025 {
+		t.Errorf("SumPaymentsChecked(): credit must not be summed, got %v, error = %v", sum, err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentNotRejectable {
+		t.Errorf("Reject(): must return ErrPaymentNotRejectable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 10)
+	if err != ErrPaymentNotRefundable {
+		t.Errorf("Refund(): must return ErrPaymentNotRefundable, but error = %v", err)
+		return
+	}
+
+	_, err = s.OpenDispute(payment.ID, "not mine")
+	if err != ErrPaymentNotRefundable {
+		t.Errorf("OpenDispute(): must return ErrPaymentNotRefundable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(history[0].ID)
+	if err != ErrPaymentNotRepeatable {
+		t.Errorf("Repeat(): credit must not be repeatable, but error = %v", err)
+		return
+	}
+
+	repeated, err := s.Repeat(payment.ID)
+	if err != nil || repeated.Category != TransferCategory || account.Balance != 7_950 || target.Balance != 2_000 {
+		t.Errorf("Repeat(): transfer must be made again, balances = %v and %v, error = %v", account.Balance, target.Balance, err)
+		return
+	}
+}
+
+func TestService_Transfer_fail(t *testing.T) {
+	s := newTestService()
+	account, err := s.addFeeAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(account.ID, "dollars", "USD", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	target, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		to		int64
+		amount	types.Money
+		want	error
+	} {
+		{ name: "zero amount",		to: target.ID,	amount: 0,		want: ErrAmountMustBePositive },
+		{ name: "same account",		to: account.ID,	amount: 100,	want: ErrTransferToSelf },
+		{ name: "unknown target",	to: 100,		amount: 100,	want: ErrAccountNotFound },
+		{ name: "other currency",	to: dollars.ID,	amount: 100,	want: ErrCurrencyMismatch },
+		{ name: "fee over balance",	to: target.ID,	amount: 9_990,	want: ErrNotEnoughBalance },
+	}
+
+	for _, test := range tests {
+		_, err = s.Transfer(account.ID, test.to, test.amount)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Transfer(): %s: must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+
+	if account.Balance != 10_000 || target.Balance != 0 || len(s.payments) != 0 {
+		t.Errorf("Transfer(): nothing must change, balances = %v and %v", account.Balance, target.Balance)
+		return
+	}
+}
+
+func TestService_Reject_transferParams(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	target, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 400, "shop")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// params of template payments are set by the customer
+	payment.Params = map[string]string{ "transfer": payment.ID, "to": strconv.FormatInt(target.ID, 10) }
+
+	repeated, err := s.Repeat(payment.ID)
+	if err != nil || repeated.Category != "shop" || target.Balance != 0 {
+		t.Errorf("Repeat(): payment is not a transfer, repeated = %+v, target balance = %v, error = %v", repeated, target.Balance, err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil || account.Balance != 600 {
+		t.Errorf("Reject(): payment is not a transfer, balance = %v, error = %v", account.Balance, err)
+		return
+	}
+}
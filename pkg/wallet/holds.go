@@ -0,0 +1,312 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrHoldNotFound - hold does not exist
+var ErrHoldNotFound = errors.New("Hold not found")
+
+// ErrHoldNotActive - hold is already captured, voided or expired
+var ErrHoldNotActive = errors.New("Hold is not active")
+
+// ErrCaptureExceedsHold - captured amount is more than the hold
+var ErrCaptureExceedsHold = errors.New("Capture amount is more than the hold")
+
+// DefaultHoldTTL - time after which not captured holds expire
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+// Authorize reserves amount on the account, it reduces available but not ledger balance
+func (s *Service) Authorize(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Hold, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(account, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	err = s.checkLimits(accountID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Available() < amount {
+		return nil, ErrNotEnoughBalance
+	}
+
+	held, err := account.Held.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	hold := &types.Hold {
+		ID:			s.newID(),
+		AccountID:	accountID,
+		Amount:		amount,
+		Category:	category,
+		Status:		types.HoldStatusActive,
+		CreatedAt:	now.Unix(),
+		ExpiresAt:	now.Add(s.holdTTL()).Unix(),
+	}
+
+	account.Held = held
+	s.holds = append(s.holds, hold)
+	s.logger().Log(LevelInfo, "hold authorized", Op("authorize"), AccountID(accountID), Field{ Key: "hold", Value: hold.ID })
+
+	return hold, nil
+}
+
+// Capture charges amount of the hold, the rest of the hold is released
+func (s *Service) Capture(holdID string, amount types.Money) (*types.Payment, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	hold, account, err := s.activeHold(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(account, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount > hold.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	balance, err := account.Balance.Sub(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := account.Held.Sub(hold.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Held = held
+	account.Balance = balance
+
+	payment := s.closeHold(hold, types.HoldStatusCaptured, amount, types.PaymentStatusInProgress)
+	s.logger().Log(LevelInfo, "hold captured", Op("capture"), AccountID(account.ID), PaymentID(payment.ID))
+
+	return payment, nil
+}
+
+// Void releases the hold without charging the account
+func (s *Service) Void(holdID string) (*types.Payment, error) {
+	hold, account, err := s.activeHold(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := account.Held.Sub(hold.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Held = held
+
+	payment := s.closeHold(hold, types.HoldStatusVoided, hold.Amount, types.PaymentStatusVoided)
+	s.logger().Log(LevelInfo, "hold voided", Op("void"), AccountID(account.ID), PaymentID(payment.ID))
+
+	return payment, nil
+}
+
+// ExpireHolds releases active holds which are past their expiry time
+func (s *Service) ExpireHolds() []*types.Payment {
+	var payments []*types.Payment
+
+	now := s.now().Unix()
+	for _, hold := range s.holds {
+		if hold.Status != types.HoldStatusActive || hold.ExpiresAt > now {
+			continue
+		}
+
+		account, err := s.FindAccountByID(hold.AccountID)
+		if err == nil {
+			held, err := account.Held.Sub(hold.Amount)
+			if err != nil {
+				s.logger().Log(LevelError, "can't release hold", Op("expire"), AccountID(account.ID), Err(err))
+				continue
+			}
+
+			account.Held = held
+		}
+
+		payment := s.closeHold(hold, types.HoldStatusExpired, hold.Amount, types.PaymentStatusExpired)
+		s.logger().Log(LevelInfo, "hold expired", Op("expire"), AccountID(hold.AccountID), PaymentID(payment.ID))
+
+		payments = append(payments, payment)
+	}
+
+	return payments
+}
+
+// FindHoldByID returns hold by id
+func (s *Service) FindHoldByID(holdID string) (*types.Hold, error) {
+	for _, hold := range s.holds {
+		if hold.ID == holdID {
+			return hold, nil
+		}
+	}
+
+	return nil, ErrHoldNotFound
+}
+
+// GetHolds returns holds
+func (s *Service) GetHolds() []*types.Hold {
+	return s.holds
+}
+
+// holdTTL returns configured expiry time of holds
+func (s *Service) holdTTL() time.Duration {
+	if s.holdExpiry <= 0 {
+		return DefaultHoldTTL
+	}
+
+	return s.holdExpiry
+}
+
+// activeHold returns the hold and its account, expired holds are released first
+func (s *Service) activeHold(holdID string) (*types.Hold, *types.Account, error) {
+	hold, err := s.FindHoldByID(holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.ExpireHolds()
+	if hold.Status != types.HoldStatusActive {
+		return nil, nil, ErrHoldNotActive
+	}
+
+	account, err := s.FindAccountByID(hold.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, account, nil
+}
+
+// released reports whether the payment records a voided or expired hold, no money was moved by it
+func released(payment *types.Payment) bool {
+	return payment.Status == types.PaymentStatusVoided || payment.Status == types.PaymentStatusExpired
+}
+
+// closeHold records payment for the hold and moves it to the final status
+func (s *Service) closeHold(hold *types.Hold, status types.HoldStatus, amount types.Money, paymentStatus types.PaymentStatus) *types.Payment {
+	payment := &types.Payment {
+		ID:			s.newID(),
+		AccountID:	hold.AccountID,
+		Amount:		amount,
+		Category:	hold.Category,
+		Status:		paymentStatus,
+		CreatedAt:	s.now().Unix(),
+		Currency:	s.currencyOf(hold.AccountID),
+	}
+
+	s.payments = append(s.payments, payment)
+	hold.Status = status
+	hold.PaymentID = payment.ID
+
+	return payment
+}
+
+// recountHeld sets held balance of accounts from active holds, OverflowError if it doesn't fit
+func (s *Service) recountHeld() error {
+	for _, account := range s.accounts {
+		account.Held = 0
+	}
+
+	for _, hold := range s.holds {
+		if hold.Status != types.HoldStatusActive {
+			continue
+		}
+
+		account, err := s.FindAccountByID(hold.AccountID)
+		if err != nil {
+			continue
+		}
+
+		held, err := account.Held.Add(hold.Amount)
+		if err != nil {
+			return err
+		}
+
+		account.Held = held
+	}
+
+	return nil
+}
+
+func (s *Service) parseHoldToString(hold *types.Hold) string {
+	parsed := hold.ID + ";"
+	parsed += strconv.FormatInt(hold.AccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(hold.Amount), 10) + ";"
+	parsed += string(hold.Category) + ";"
+	parsed += string(hold.Status) + ";"
+	parsed += strconv.FormatInt(hold.CreatedAt, 10) + ";"
+	parsed += strconv.FormatInt(hold.ExpiresAt, 10) + ";"
+	parsed += hold.PaymentID + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToHolds(data string) []*types.Hold {
+	var holds []*types.Hold
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 8 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[1], 10, 64)
+		amount, _		:= strconv.ParseInt(item[2], 10, 64)
+		createdAt, _	:= strconv.ParseInt(item[5], 10, 64)
+		expiresAt, _	:= strconv.ParseInt(item[6], 10, 64)
+
+		hold := &types.Hold {
+			ID:			item[0],
+			AccountID:	accountID,
+			Amount:		types.Money(amount),
+			Category:	types.PaymentCategory(item[3]),
+			Status:		types.HoldStatus(item[4]),
+			CreatedAt:	createdAt,
+			ExpiresAt:	expiresAt,
+			PaymentID:	item[7],
+		}
+
+		holds = append(holds, hold)
+	}
+
+	return holds
+}
+
+func (s *Service) containsHold(item *types.Hold, items []*types.Hold) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,293 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_Authorize_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 600, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	if hold.Status != types.HoldStatusActive {
+		t.Errorf("Authorize(): wrong status = %v", hold.Status)
+		return
+	}
+
+	if account.Balance != 1_000 || account.Held != 600 || account.Available() != 400 {
+		t.Errorf("Authorize(): wrong balances = %+v, available = %v", account, account.Available())
+		return
+	}
+
+	_, err = s.Pay(account.ID, 500, "food")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, but error = %v", err)
+		return
+	}
+
+	_, err = s.Authorize(account.ID, 500, "hotel")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Authorize(): must return ErrNotEnoughBalance, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Capture_partial(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 600, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	_, err = s.Capture(hold.ID, 700)
+	if err != ErrCaptureExceedsHold {
+		t.Errorf("Capture(): must return ErrCaptureExceedsHold, but error = %v", err)
+		return
+	}
+
+	payment, err := s.Capture(hold.ID, 450)
+	if err != nil {
+		t.Errorf("Capture(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 450 || payment.Status != types.PaymentStatusInProgress || payment.Category != "hotel" {
+		t.Errorf("Capture(): wrong payment = %+v", payment)
+		return
+	}
+
+	if hold.Status != types.HoldStatusCaptured || hold.PaymentID != payment.ID {
+		t.Errorf("Capture(): wrong hold = %+v", hold)
+		return
+	}
+
+	if account.Balance != 550 || account.Held != 0 {
+		t.Errorf("Capture(): wrong balances = %+v", account)
+		return
+	}
+
+	_, err = s.Capture(hold.ID, 100)
+	if err != ErrHoldNotActive {
+		t.Errorf("Capture(): must return ErrHoldNotActive, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Void_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 600, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	payment, err := s.Void(hold.ID)
+	if err != nil {
+		t.Errorf("Void(): error = %v", err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusVoided || hold.Status != types.HoldStatusVoided {
+		t.Errorf("Void(): wrong payment = %+v, hold = %+v", payment, hold)
+		return
+	}
+
+	if account.Balance != 1_000 || account.Held != 0 {
+		t.Errorf("Void(): wrong balances = %+v", account)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentNotRejectable {
+		t.Errorf("Reject(): must return ErrPaymentNotRejectable, but error = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(payment.ID)
+	if err != ErrPaymentNotRepeatable {
+		t.Errorf("Repeat(): must return ErrPaymentNotRepeatable, but error = %v", err)
+		return
+	}
+
+	sum, err := s.SumPaymentsChecked(1)
+	if err != nil || sum != 0 {
+		t.Errorf("SumPaymentsChecked(): voided hold must not be summed, got %v, error = %v", sum, err)
+		return
+	}
+
+	_, err = s.Void("unknown")
+	if err != ErrHoldNotFound {
+		t.Errorf("Void(): must return ErrHoldNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_ExpireHolds_success(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 600, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	if len(s.ExpireHolds()) != 0 {
+		t.Error("ExpireHolds(): hold must not expire before ttl")
+		return
+	}
+
+	clock.Advance(DefaultHoldTTL)
+
+	_, err = s.Capture(hold.ID, 600)
+	if err != ErrHoldNotActive {
+		t.Errorf("Capture(): must return ErrHoldNotActive, but error = %v", err)
+		return
+	}
+
+	if hold.Status != types.HoldStatusExpired || account.Held != 0 {
+		t.Errorf("ExpireHolds(): hold = %+v, account = %+v", hold, account)
+		return
+	}
+
+	payment, err := s.FindPaymentByID(hold.PaymentID)
+	if err != nil || payment.Status != types.PaymentStatusExpired {
+		t.Errorf("ExpireHolds(): wrong payment = %+v, error = %v", payment, err)
+		return
+	}
+
+	_, err = s.Repeat(payment.ID)
+	if err != ErrPaymentNotRepeatable {
+		t.Errorf("Repeat(): must return ErrPaymentNotRepeatable, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Authorize_limits(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimit(types.SpendingLimit{ AccountID: account.ID, Daily: 500 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 400, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 200, "food")
+	assertLimitError(t, err, LimitDaily, 100)
+
+	_, err = s.Void(hold.ID)
+	if err != nil {
+		t.Errorf("Void(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 200, "food")
+	if err != nil {
+		t.Errorf("Pay(): voided hold must not count, error = %v", err)
+		return
+	}
+}
+
+func TestService_Export_holds(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Authorize(account.ID, 300, "hotel")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	voided, err := s.Authorize(account.ID, 100, "taxi")
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	_, err = s.Void(voided.ID)
+	if err != nil {
+		t.Errorf("Void(): error = %v", err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	if len(imported.GetHolds()) != 2 {
+		t.Errorf("importFrom(): wrong holds = %v", imported.GetHolds())
+		return
+	}
+
+	restored, err := imported.FindAccountByID(account.ID)
+	if err != nil {
+		t.Errorf("FindAccountByID(): error = %v", err)
+		return
+	}
+
+	if restored.Balance != 1_000 || restored.Held != 300 {
+		t.Errorf("importFrom(): wrong balances = %+v", restored)
+		return
+	}
+}
@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+	"github.com/google/uuid"
+)
+
+// IDGenerator - source of ids for payments, favorites and other records
+type IDGenerator interface {
+	NewID() string
+}
+
+// Clock - source of the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// UUIDv4Generator generates random UUIDs, used by default
+type UUIDv4Generator struct{}
+
+// NewID returns random UUID
+func (UUIDv4Generator) NewID() string {
+	return uuid.New().String()
+}
+
+// UUIDv7Generator generates time-ordered UUIDs, ids generated within the same
+// millisecond are ordered by a counter
+type UUIDv7Generator struct {
+	mu		sync.Mutex
+	clock	Clock
+	lastMs	int64
+	seq		uint16
+}
+
+// NewUUIDv7Generator creates generator taking time from the clock
+func NewUUIDv7Generator(clock Clock) *UUIDv7Generator {
+	return &UUIDv7Generator{ clock: clock }
+}
+
+// NewID returns UUID version 7
+func (g *UUIDv7Generator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var id uuid.UUID
+	_, err := rand.Read(id[6:])
+	if err != nil {
+		panic(err)
+	}
+
+	ms := g.clock.Now().UnixNano() / int64(time.Millisecond)
+	if ms <= g.lastMs {
+		ms = g.lastMs
+		g.seq++
+		if g.seq > 0x0fff {
+			ms++
+			g.seq = 0
+		}
+	} else {
+		// leave half of the counter for ids of the same millisecond
+		g.seq = binary.BigEndian.Uint16(id[6:8]) & 0x07ff
+	}
+	g.lastMs = ms
+
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	id[6] = 0x70 | byte(g.seq >> 8)
+	id[7] = byte(g.seq)
+	id[8] = id[8] & 0x3f | 0x80
+
+	return id.String()
+}
+
+// SequentialGenerator generates predictable UUID-shaped ids 00000000-0000-0000-0000-000000000001, ...
+// for tests and reproducible exports
+type SequentialGenerator struct {
+	mu		sync.Mutex
+	next	int64
+}
+
+// NewID returns next id of the sequence
+func (g *SequentialGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.next++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", g.next)
+}
+
+// SystemClock returns the system time, used by default
+type SystemClock struct{}
+
+// Now returns current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock - clock which moves only when told to, for tests
+type ManualClock struct {
+	mu		sync.Mutex
+	now		time.Time
+}
+
+// NewManualClock creates clock stopped at the given time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{ now: now }
+}
+
+// Now returns time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set moves clock to the given time
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Advance moves clock forward
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
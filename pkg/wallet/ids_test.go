@@ -0,0 +1,106 @@
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/google/uuid"
+)
+
+func TestUUIDv7Generator_NewID_ordered(t *testing.T) {
+	clock := NewManualClock(time.Date(2020, 10, 22, 0, 0, 0, 0, time.UTC))
+	ids := NewUUIDv7Generator(clock)
+
+	previous := ""
+	for i := 0; i < 10_000; i++ {
+		if i % 100 == 0 {
+			clock.Advance(time.Millisecond)
+		}
+
+		id := ids.NewID()
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			t.Errorf("NewID(): invalid uuid = %v, error = %v", id, err)
+			return
+		}
+
+		if parsed.Version() != 7 || parsed.Variant() != uuid.RFC4122 {
+			t.Errorf("NewID(): wrong version or variant = %v", id)
+			return
+		}
+
+		if id <= previous {
+			t.Errorf("NewID(): ids must be ordered, %v after %v", id, previous)
+			return
+		}
+		previous = id
+	}
+}
+
+func TestSequentialGenerator_NewID_success(t *testing.T) {
+	ids := &SequentialGenerator{}
+
+	if ids.NewID() != "00000000-0000-0000-0000-000000000001" || ids.NewID() != "00000000-0000-0000-0000-000000000002" {
+		t.Error("NewID(): wrong sequence")
+	}
+}
+
+func TestService_Export_reproducible(t *testing.T) {
+	var dumps [][]byte
+	for i := 0; i < 2; i++ {
+		dir, err := ioutil.TempDir("", "wallet")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		clock := NewManualClock(time.Date(2020, 10, 22, 0, 0, 0, 0, time.UTC))
+		svc, err := NewService(WithIDGenerator(&SequentialGenerator{}), WithClock(clock))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		s := &testService{ Service: svc }
+
+		_, _, _, err = s.addAcount(defaultTestAccount)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = s.Export(dir)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var dump []byte
+		for _, name := range []string{ "accounts.dump", "payments.dump", "favorites.dump" } {
+			data, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			dump = append(dump, data...)
+		}
+		dumps = append(dumps, dump)
+	}
+
+	if !bytes.Equal(dumps[0], dumps[1]) {
+		t.Errorf("Export(): dumps must be equal:\n%s\n%s", dumps[0], dumps[1])
+		return
+	}
+
+	want := "1;+992937452945;900000\n" +
+		"00000000-0000-0000-0000-000000000001;1;100000;auto;INPROGRESS;1603324800\n" +
+		"00000000-0000-0000-0000-000000000002;1;favorite;100000;auto;0;0;0\n"
+	if string(dumps[0]) != want {
+		t.Errorf("Export(): got\n%s\nwant\n%s", dumps[0], want)
+		return
+	}
+}
@@ -0,0 +1,172 @@
+package wallet
+
+import (
+	"errors"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrAccountFrozen - frozen account can't spend money
+var ErrAccountFrozen = errors.New("Account is frozen")
+
+// ErrAccountClosed - closed account can't be changed
+var ErrAccountClosed = errors.New("Account is closed")
+
+// ErrAccountNotEmpty - account has money, active holds, open disputes or open pockets
+var ErrAccountNotEmpty = errors.New("Account must have zero balance, no holds, no open disputes and no open pockets")
+
+// ErrInvalidAccountStatus - account is already in the requested status
+var ErrInvalidAccountStatus = errors.New("Invalid account status transition")
+
+// PayoutCategory - category of the payment made by CloseWithPayout
+const PayoutCategory types.PaymentCategory = "payout"
+
+// Freeze stops spending from the account, deposits are still accepted
+func (s *Service) Freeze(accountID int64, reason string) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkAccount(account, true)
+	if err == ErrAccountFrozen {
+		return ErrInvalidAccountStatus
+	}
+	if err != nil {
+		return err
+	}
+
+	s.setAccountStatus(account, types.AccountStatusFrozen, reason)
+	return nil
+}
+
+// Unfreeze makes frozen account active again
+func (s *Service) Unfreeze(accountID int64, reason string) error {
+	account, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status != types.AccountStatusFrozen {
+		return ErrInvalidAccountStatus
+	}
+
+	s.setAccountStatus(account, types.AccountStatusActive, reason)
+	return nil
+}
+
+// Close closes active or frozen account, it must have zero balance, no active holds,
+// no open disputes and no open pockets, schedules of its favorites are removed
+func (s *Service) Close(accountID int64, reason string) error {
+	account, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Balance != 0 || s.hasObligations(account) {
+		return ErrAccountNotEmpty
+	}
+
+	var schedules []*types.Schedule
+	for _, schedule := range s.schedules {
+		favorite, err := s.FindFavoriteByID(schedule.FavoriteID)
+		if err == nil && favorite.AccountID == accountID {
+			continue
+		}
+		schedules = append(schedules, schedule)
+	}
+	s.schedules = schedules
+
+	s.setAccountStatus(account, types.AccountStatusClosed, reason)
+	return nil
+}
+
+// CloseWithPayout pays out the whole balance of the account and closes it, payout
+// is not checked against spending limits and is made from frozen accounts too
+func (s *Service) CloseWithPayout(accountID int64, reason string) (*types.Payment, error) {
+	account, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.hasObligations(account) {
+		return nil, ErrAccountNotEmpty
+	}
+
+	if account.Balance <= 0 {
+		return nil, s.Close(accountID, reason)
+	}
+
+	payment := &types.Payment {
+		ID:			s.newID(),
+		AccountID:	accountID,
+		Amount:		account.Balance,
+		Category:	PayoutCategory,
+		Status:		types.PaymentStatusOk,
+		CreatedAt:	s.now().Unix(),
+		Currency:	AccountCurrency(account),
+	}
+
+	account.Balance = 0
+	s.payments = append(s.payments, payment)
+	s.logger().Log(LevelInfo, "payout", Op("close"), AccountID(accountID), PaymentID(payment.ID))
+
+	err = s.Close(accountID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// checkAccount returns error if the account is closed, or frozen when money is spent
+func (s *Service) checkAccount(account *types.Account, spend bool) error {
+	switch account.Status {
+	case types.AccountStatusClosed:
+		return ErrAccountClosed
+	case types.AccountStatusFrozen:
+		if spend {
+			return ErrAccountFrozen
+		}
+	}
+
+	return nil
+}
+
+// findOpenAccount returns the account if it is not closed
+func (s *Service) findOpenAccount(accountID int64) (*types.Account, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(account, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// hasObligations reports whether the account has active holds, open disputes or open pockets
+func (s *Service) hasObligations(account *types.Account) bool {
+	s.ExpireHolds()
+	if account.Held != 0 || s.openPockets(account) {
+		return true
+	}
+
+	for _, dispute := range s.disputes {
+		if dispute.AccountID == account.ID && disputeActive(dispute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) setAccountStatus(account *types.Account, status types.AccountStatus, reason string) {
+	account.Status = status
+	account.StatusReason = reason
+	account.StatusChangedAt = s.now().Unix()
+
+	s.logger().Log(LevelInfo, "account status changed", Op("status"), AccountID(account.ID), Field{ Key: "status", Value: status })
+}
@@ -0,0 +1,253 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_Freeze_success(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Freeze(account.ID, "suspicious activity")
+	if err != nil {
+		t.Errorf("Freeze(): error = %v", err)
+		return
+	}
+
+	if account.Status != types.AccountStatusFrozen || account.StatusReason != "suspicious activity" || account.StatusChangedAt != clock.Now().Unix() {
+		t.Errorf("Freeze(): wrong account = %+v", account)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "shop")
+	if err != ErrAccountFrozen {
+		t.Errorf("Pay(): must return ErrAccountFrozen, but error = %v", err)
+		return
+	}
+
+	_, err = s.Authorize(account.ID, 100, "shop")
+	if err != ErrAccountFrozen {
+		t.Errorf("Authorize(): must return ErrAccountFrozen, but error = %v", err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 100)
+	if err != nil {
+		t.Errorf("Deposit(): frozen account accepts deposits, error = %v", err)
+		return
+	}
+
+	err = s.Freeze(account.ID, "again")
+	if err != ErrInvalidAccountStatus {
+		t.Errorf("Freeze(): must return ErrInvalidAccountStatus, but error = %v", err)
+		return
+	}
+
+	err = s.Unfreeze(account.ID, "checked")
+	if err != nil {
+		t.Errorf("Unfreeze(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	err = s.Unfreeze(account.ID, "again")
+	if err != ErrInvalidAccountStatus {
+		t.Errorf("Unfreeze(): must return ErrInvalidAccountStatus, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Close_notEmpty(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Close(account.ID, "customer request")
+	if err != ErrAccountNotEmpty {
+		t.Errorf("Close(): must return ErrAccountNotEmpty, but error = %v", err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 1_000, "shop")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.OpenDispute(payment.ID, "broken")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.CloseWithPayout(account.ID, "customer request")
+	if err != ErrAccountNotEmpty {
+		t.Errorf("CloseWithPayout(): must return ErrAccountNotEmpty, but error = %v", err)
+		return
+	}
+
+	if account.Status != types.AccountStatusActive || account.Balance != 1_000 {
+		t.Errorf("CloseWithPayout(): account must not change = %+v", account)
+		return
+	}
+}
+
+func TestService_CloseWithPayout_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100, "internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorite, err := s.FavoritePayment(payment.ID, "internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SchedulePayment(favorite.ID, "daily 09:00")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	template, err := s.CreateTemplate(types.Template{ AccountID: account.ID, Name: "internet", Category: "internet" })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Freeze(account.ID, "moving abroad")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payout, err := s.CloseWithPayout(account.ID, "customer request")
+	if err != nil {
+		t.Errorf("CloseWithPayout(): error = %v", err)
+		return
+	}
+
+	if payout.Amount != 900 || payout.Category != PayoutCategory || account.Balance != 0 || account.Status != types.AccountStatusClosed {
+		t.Errorf("CloseWithPayout(): wrong payout = %+v, account = %+v", payout, account)
+		return
+	}
+
+	if len(s.GetSchedules()) != 0 {
+		t.Errorf("CloseWithPayout(): schedules must be removed = %v", s.GetSchedules())
+		return
+	}
+
+	tests := []struct {
+		name	string
+		fn		func() error
+	} {
+		{ name: "Deposit",	fn: func() error { return s.Deposit(account.ID, 100) } },
+		{ name: "Unfreeze",	fn: func() error { return s.Unfreeze(account.ID, "") } },
+		{ name: "Freeze",	fn: func() error { return s.Freeze(account.ID, "") } },
+		{ name: "Close",	fn: func() error { return s.Close(account.ID, "") } },
+		{ name: "Reject",	fn: func() error { return s.Reject(payment.ID) } },
+		{ name: "Refund",	fn: func() error { _, err := s.Refund(payment.ID, 10); return err } },
+		{ name: "UpdateFavorite", fn: func() error { _, err := s.UpdateFavorite(favorite.ID, "isp", 10, "isp"); return err } },
+		{ name: "DeleteFavorite", fn: func() error { return s.DeleteFavorite(favorite.ID) } },
+		{ name: "ReorderFavorites", fn: func() error { return s.ReorderFavorites(account.ID, []string{ favorite.ID }) } },
+		{ name: "DeleteTemplate", fn: func() error { return s.DeleteTemplate(template.ID) } },
+		{ name: "SetSpendingLimit", fn: func() error { return s.SetSpendingLimit(types.SpendingLimit{ AccountID: account.ID }) } },
+	}
+
+	for _, test := range tests {
+		err := test.fn()
+		if err != ErrAccountClosed {
+			t.Errorf("%s(): must return ErrAccountClosed, but error = %v", test.name, err)
+		}
+	}
+}
+
+func TestService_Export_accountStatus(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	other, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	clock.Advance(time.Hour)
+	err = s.Freeze(account.ID, "court order; case 12|7")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	restored, err := imported.FindAccountByID(account.ID)
+	if err != nil {
+		t.Errorf("FindAccountByID(): error = %v", err)
+		return
+	}
+
+	if *restored != *account {
+		t.Errorf("importFrom(): got %+v, want %+v", restored, account)
+		return
+	}
+
+	restored, err = imported.FindAccountByID(other.ID)
+	if err != nil || restored.Status != types.AccountStatusActive {
+		t.Errorf("importFrom(): wrong account = %+v, error = %v", restored, err)
+		return
+	}
+
+	parsed := s.parseStringToAccounts(s.parseAccountToString(account, "|"), "|")[0]
+	if *parsed != *account {
+		t.Errorf("parseStringToAccounts(): got %+v, want %+v", parsed, account)
+		return
+	}
+}
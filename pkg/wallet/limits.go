@@ -0,0 +1,306 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrSpendingLimitNotFound - account has no spending limits
+var ErrSpendingLimitNotFound = errors.New("Spending limit not found")
+
+// ErrInvalidSpendingLimit - spending limit has negative values
+var ErrInvalidSpendingLimit = errors.New("Spending limit must not be negative")
+
+// Names of limits reported in LimitError
+const (
+	LimitMaxPayment	= "max payment"
+	LimitMaxDeposit	= "max deposit"
+	LimitMaxBalance	= "max balance"
+	LimitPerPayment	= "per payment"
+	LimitDaily		= "daily"
+	LimitWeekly		= "weekly"
+	LimitMonthly	= "monthly"
+	LimitCategory	= "category"
+
+	LimitMemberPerPayment	= "member per payment"
+	LimitMemberDaily		= "member daily"
+	LimitMemberMonthly		= "member monthly"
+)
+
+// LimitError - operation exceeds the limit, matches ErrLimitExceeded
+type LimitError struct {
+	Limit		string
+	Category	types.PaymentCategory
+	Max			types.Money
+	Remaining	types.Money
+}
+
+// Error returns which limit was hit and remaining allowance
+func (e *LimitError) Error() string {
+	name := e.Limit
+	if e.Limit == LimitCategory {
+		name = "category " + string(e.Category)
+	}
+
+	return fmt.Sprintf("%s: %s limit is %d, remaining %d", ErrLimitExceeded, name, e.Max, e.Remaining)
+}
+
+// Unwrap returns ErrLimitExceeded
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// SetSpendingLimit sets or replaces spending limits of the account
+func (s *Service) SetSpendingLimit(limit types.SpendingLimit) error {
+	_, err := s.findOpenAccount(limit.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if limit.PerPayment < 0 || limit.Daily < 0 || limit.Weekly < 0 || limit.Monthly < 0 {
+		return ErrInvalidSpendingLimit
+	}
+
+	categories := make(map[types.PaymentCategory]types.Money)
+	for category, amount := range limit.Categories {
+		if amount < 0 {
+			return ErrInvalidSpendingLimit
+		}
+		categories[category] = amount
+	}
+	limit.Categories = categories
+
+	s.logger().Log(LevelInfo, "spending limit set", Op("limit"), AccountID(limit.AccountID))
+	for _, saved := range s.spendingLimits {
+		if saved.AccountID == limit.AccountID {
+			*saved = limit
+			return nil
+		}
+	}
+
+	s.spendingLimits = append(s.spendingLimits, &limit)
+	return nil
+}
+
+// FindSpendingLimit returns spending limits of the account
+func (s *Service) FindSpendingLimit(accountID int64) (*types.SpendingLimit, error) {
+	for _, limit := range s.spendingLimits {
+		if limit.AccountID == accountID {
+			return limit, nil
+		}
+	}
+
+	return nil, ErrSpendingLimitNotFound
+}
+
+// RemoveSpendingLimit removes all spending limits of the account
+func (s *Service) RemoveSpendingLimit(accountID int64) error {
+	for i, limit := range s.spendingLimits {
+		if limit.AccountID == accountID {
+			s.spendingLimits = append(s.spendingLimits[:i], s.spendingLimits[i + 1:]...)
+			return nil
+		}
+	}
+
+	return ErrSpendingLimitNotFound
+}
+
+// checkLimits returns LimitError if the payment exceeds service or account limits,
+// periods are calendar day, week starting on Monday and month in UTC
+func (s *Service) checkLimits(accountID int64, amount types.Money, category types.PaymentCategory) error {
+	limits := s.limitsOf(s.currencyOf(accountID))
+	if limits.MaxPayment > 0 && amount > limits.MaxPayment {
+		return &LimitError{ Limit: LimitMaxPayment, Max: limits.MaxPayment, Remaining: limits.MaxPayment }
+	}
+
+	limit, err := s.FindSpendingLimit(accountID)
+	if err != nil {
+		return nil
+	}
+
+	if limit.PerPayment > 0 && amount > limit.PerPayment {
+		return &LimitError{ Limit: LimitPerPayment, Max: limit.PerPayment, Remaining: limit.PerPayment }
+	}
+
+	day, week, month := periodStarts(s.now())
+
+	periods := []struct {
+		name	string
+		max		types.Money
+		from	time.Time
+	} {
+		{ name: LimitDaily,		max: limit.Daily,	from: day },
+		{ name: LimitWeekly,	max: limit.Weekly,	from: week },
+		{ name: LimitMonthly,	max: limit.Monthly,	from: month },
+	}
+
+	for _, period := range periods {
+		if period.max == 0 {
+			continue
+		}
+
+		spent, err := s.spentSince(accountID, "", period.from)
+		if err != nil {
+			return err
+		}
+
+		err = exceeds(period.name, "", period.max, spent, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	max, ok := limit.Categories[category]
+	if ok && max > 0 {
+		spent, err := s.spentSince(accountID, category, month)
+		if err != nil {
+			return err
+		}
+
+		return exceeds(LimitCategory, category, max, spent, amount)
+	}
+
+	return nil
+}
+
+// spentSince sums charged payments less refunds and active holds of the account, empty category means all,
+// ErrOverflow if the sum doesn't fit into types.Money
+func (s *Service) spentSince(accountID int64, category types.PaymentCategory, from time.Time) (types.Money, error) {
+	spent := money.Sum{}
+	since := from.Unix()
+
+	for _, payment := range s.payments {
+		if payment.AccountID != accountID || payment.CreatedAt < since || !charged(payment.Status) || exchangeCredit(payment) || transferCredit(payment) {
+			continue
+		}
+
+		if category != "" && payment.Category != category {
+			continue
+		}
+
+		spent.Add(payment.Amount)
+		spent.Add(-s.refunded(payment.ID))
+	}
+
+	for _, hold := range s.holds {
+		if hold.AccountID != accountID || hold.CreatedAt < since || hold.Status != types.HoldStatusActive {
+			continue
+		}
+
+		if category != "" && hold.Category != category {
+			continue
+		}
+
+		spent.Add(hold.Amount)
+	}
+
+	return spent.Money()
+}
+
+// periodStarts returns starts of the calendar day, week and month of the time in UTC
+func periodStarts(now time.Time) (time.Time, time.Time, time.Time) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	week := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return day, week, month
+}
+
+func charged(status types.PaymentStatus) bool {
+	return status != types.PaymentStatusFail && status != types.PaymentStatusVoided && status != types.PaymentStatusExpired
+}
+
+func exceeds(name string, category types.PaymentCategory, max types.Money, spent types.Money, amount types.Money) error {
+	// compared without adding, so huge amounts can't wrap around the limit
+	if spent <= max && amount <= max - spent {
+		return nil
+	}
+
+	remaining := max - spent
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &LimitError {
+		Limit:		name,
+		Category:	category,
+		Max:		max,
+		Remaining:	remaining,
+	}
+}
+
+func (s *Service) parseSpendingLimitToString(limit *types.SpendingLimit) string {
+	categories := make([]string, 0, len(limit.Categories))
+	for category, amount := range limit.Categories {
+		categories = append(categories, string(category) + "=" + strconv.FormatInt(int64(amount), 10))
+	}
+	sort.Strings(categories)
+
+	parsed := strconv.FormatInt(limit.AccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(limit.PerPayment), 10) + ";"
+	parsed += strconv.FormatInt(int64(limit.Daily), 10) + ";"
+	parsed += strconv.FormatInt(int64(limit.Weekly), 10) + ";"
+	parsed += strconv.FormatInt(int64(limit.Monthly), 10) + ";"
+	parsed += strings.Join(categories, ",") + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToSpendingLimits(data string) []*types.SpendingLimit {
+	var limits []*types.SpendingLimit
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 6 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[0], 10, 64)
+		perPayment, _	:= strconv.ParseInt(item[1], 10, 64)
+		daily, _		:= strconv.ParseInt(item[2], 10, 64)
+		weekly, _		:= strconv.ParseInt(item[3], 10, 64)
+		monthly, _		:= strconv.ParseInt(item[4], 10, 64)
+
+		limit := &types.SpendingLimit {
+			AccountID:	accountID,
+			PerPayment:	types.Money(perPayment),
+			Daily:		types.Money(daily),
+			Weekly:		types.Money(weekly),
+			Monthly:	types.Money(monthly),
+			Categories:	make(map[types.PaymentCategory]types.Money),
+		}
+
+		for _, pair := range strings.Split(item[5], ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			amount, _ := strconv.ParseInt(parts[1], 10, 64)
+			limit.Categories[types.PaymentCategory(parts[0])] = types.Money(amount)
+		}
+
+		limits = append(limits, limit)
+	}
+
+	return limits
+}
+
+func (s *Service) containsSpendingLimit(item *types.SpendingLimit, items []*types.SpendingLimit) bool {
+	for _, value := range items {
+		if value.AccountID == item.AccountID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
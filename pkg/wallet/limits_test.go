@@ -0,0 +1,326 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addLimitedAccount(limit types.SpendingLimit) (*types.Account, error) {
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_000)
+	if err != nil {
+		return nil, err
+	}
+
+	limit.AccountID = account.ID
+	err = s.SetSpendingLimit(limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't set spending limit, error = %v", err)
+	}
+
+	return account, nil
+}
+
+func assertLimitError(t *testing.T, err error, name string, remaining types.Money) {
+	t.Helper()
+
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, but error = %v", err)
+		return
+	}
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Pay(): must return LimitError, but error = %v", err)
+		return
+	}
+
+	if limitErr.Limit != name || limitErr.Remaining != remaining {
+		t.Errorf("Pay(): wrong limit error = %+v, want %v with remaining %v", limitErr, name, remaining)
+	}
+}
+
+func TestService_SetSpendingLimit_fail(t *testing.T) {
+	s := newTestService()
+
+	err := s.SetSpendingLimit(types.SpendingLimit{ AccountID: 1, Daily: 100 })
+	if err != ErrAccountNotFound {
+		t.Errorf("SetSpendingLimit(): must return ErrAccountNotFound, but error = %v", err)
+		return
+	}
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimit(types.SpendingLimit{ AccountID: account.ID, Categories: map[types.PaymentCategory]types.Money{ "auto": -1 } })
+	if err != ErrInvalidSpendingLimit {
+		t.Errorf("SetSpendingLimit(): must return ErrInvalidSpendingLimit, but error = %v", err)
+		return
+	}
+
+	err = s.RemoveSpendingLimit(account.ID)
+	if err != ErrSpendingLimitNotFound {
+		t.Errorf("RemoveSpendingLimit(): must return ErrSpendingLimitNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Pay_perPaymentLimit(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ PerPayment: 100 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 101, "auto")
+	assertLimitError(t, err, LimitPerPayment, 100)
+
+	err = s.RemoveSpendingLimit(account.ID)
+	if err != nil {
+		t.Errorf("RemoveSpendingLimit(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 101, "auto")
+	if err != nil {
+		t.Errorf("Pay(): limit removed, error = %v", err)
+		return
+	}
+}
+
+func TestService_Pay_periodLimits(t *testing.T) {
+	// Wednesday
+	clock := NewManualClock(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	s := newTestService()
+	s.clock = clock
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ Daily: 100, Weekly: 250, Monthly: 350 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 70, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 40, "auto")
+	assertLimitError(t, err, LimitDaily, 30)
+
+	// Thursday and Friday of the same week
+	clock.Advance(24 * time.Hour)
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): next day, error = %v", err)
+		return
+	}
+
+	clock.Advance(24 * time.Hour)
+	_, err = s.Pay(account.ID, 90, "auto")
+	assertLimitError(t, err, LimitWeekly, 80)
+
+	// next Monday starts a new week, but not a new month
+	clock.Set(time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC))
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): next week, error = %v", err)
+		return
+	}
+
+	clock.Advance(24 * time.Hour)
+	_, err = s.Pay(account.ID, 100, "auto")
+	assertLimitError(t, err, LimitMonthly, 80)
+
+	clock.Set(time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): next month, error = %v", err)
+		return
+	}
+}
+
+func TestService_Pay_categoryLimit(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{
+		Categories: map[types.PaymentCategory]types.Money{ "games": 50 },
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 40, "games")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 500, "food")
+	if err != nil {
+		t.Errorf("Pay(): other category, error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 20, "games")
+	assertLimitError(t, err, LimitCategory, 10)
+
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) && limitErr.Category != "games" {
+		t.Errorf("Pay(): wrong category = %v", limitErr.Category)
+		return
+	}
+}
+
+func TestService_Pay_rejectedNotCounted(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ Daily: 100 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): rejected payment must not count, error = %v", err)
+		return
+	}
+}
+
+func TestService_Pay_spentOverflow(t *testing.T) {
+	clock := NewManualClock(time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC))
+	s := newTestService()
+	s.clock = clock
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ Daily: 100 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		s.payments = append(s.payments, &types.Payment {
+			ID:			"imported",
+			AccountID:	account.ID,
+			Amount:		math.MaxInt64,
+			Category:	"auto",
+			Status:		types.PaymentStatusOk,
+			CreatedAt:	clock.Now().Unix(),
+		})
+	}
+
+	_, err = s.Pay(account.ID, 1, "auto")
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("Pay(): spent sum must not wrap around, error = %v", err)
+		return
+	}
+}
+
+func TestService_Repeat_limits(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ Daily: 150 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(payment.ID)
+	assertLimitError(t, err, LimitDaily, 50)
+
+	favorite, err := s.FavoritePayment(payment.ID, "car")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	_, err = s.PayFromFavorite(favorite.ID)
+	assertLimitError(t, err, LimitDaily, 50)
+}
+
+func TestService_Export_spendingLimits(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{
+		PerPayment:	10,
+		Daily:		20,
+		Weekly:		30,
+		Monthly:	40,
+		Categories:	map[types.PaymentCategory]types.Money{ "food": 5, "auto": 7 },
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	data, err := storage.Read(limitsDump)
+	if err != nil {
+		t.Errorf("Read(): error = %v", err)
+		return
+	}
+
+	if string(data) != "1;10;20;30;40;auto=7,food=5\n" {
+		t.Errorf("exportTo(): wrong dump = %q", data)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	limit, err := imported.FindSpendingLimit(account.ID)
+	if err != nil {
+		t.Errorf("FindSpendingLimit(): error = %v", err)
+		return
+	}
+
+	if limit.Weekly != 30 || limit.Categories["auto"] != 7 || limit.Categories["food"] != 5 {
+		t.Errorf("importFrom(): wrong limit = %+v", limit)
+		return
+	}
+}
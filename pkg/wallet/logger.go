@@ -0,0 +1,122 @@
+package wallet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level - severity of the log record
+type Level int
+
+// Supported levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Field - key and value attached to the log record
+type Field struct {
+	Key		string
+	Value	interface{}
+}
+
+// Logger - structured logger used by the service
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// Op - name of the service operation
+func Op(name string) Field {
+	return Field{ Key: "operation", Value: name }
+}
+
+// AccountID - id of the account
+func AccountID(id int64) Field {
+	return Field{ Key: "account_id", Value: id }
+}
+
+// PaymentID - id of the payment
+func PaymentID(id string) Field {
+	return Field{ Key: "payment_id", Value: id }
+}
+
+// FavoriteID - id of the favorite
+func FavoriteID(id string) Field {
+	return Field{ Key: "favorite_id", Value: id }
+}
+
+// Path - file or directory
+func Path(path string) Field {
+	return Field{ Key: "path", Value: path }
+}
+
+// Count - number of processed items
+func Count(count int) Field {
+	return Field{ Key: "count", Value: count }
+}
+
+// Err - error of the operation
+func Err(err error) Field {
+	return Field{ Key: "error", Value: err }
+}
+
+// NopLogger discards all records, used by default
+type NopLogger struct{}
+
+// Log does nothing
+func (NopLogger) Log(level Level, msg string, fields ...Field) {}
+
+// StdLogger - adapter writing records to the standard library logger as key=value pairs
+type StdLogger struct {
+	logger	*log.Logger
+	level	Level
+}
+
+// NewStdLogger creates adapter which skips records below the given level
+func NewStdLogger(logger *log.Logger, level Level) *StdLogger {
+	return &StdLogger {
+		logger:	logger,
+		level:	level,
+	}
+}
+
+// Log writes record like: level=ERROR msg="can't export" operation=export error="..."
+func (l *StdLogger) Log(level Level, msg string, fields ...Field) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("level=" + level.String())
+	b.WriteString(" msg=" + quote(msg))
+	for _, field := range fields {
+		b.WriteString(" " + field.Key + "=" + quote(fmt.Sprint(field.Value)))
+	}
+
+	l.logger.Println(b.String())
+}
+
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+		return fmt.Sprintf("%q", value)
+	}
+
+	return value
+}
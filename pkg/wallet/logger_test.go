@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	level	Level
+	msg		string
+	fields	[]Field
+}
+
+type testLogger struct {
+	records	[]record
+}
+
+func (l *testLogger) Log(level Level, msg string, fields ...Field) {
+	l.records = append(l.records, record{ level: level, msg: msg, fields: fields })
+}
+
+func TestService_WithLogger_success(t *testing.T) {
+	logger := &testLogger{}
+	s, err := NewService(WithLogger(logger))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	account, err := s.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(logger.records) != 2 {
+		t.Errorf("WithLogger(): wrong number of records = %v", len(logger.records))
+		return
+	}
+
+	first := logger.records[0]
+	if first.level != LevelInfo || first.fields[0] != Op("register") || first.fields[1] != AccountID(account.ID) {
+		t.Errorf("WithLogger(): wrong record = %v", first)
+		return
+	}
+}
+
+func TestService_Logger_noGlobalLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestService()
+	_, _, _, err = s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = (&Service{}).Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.getDataFromFile(dir + "/unknown.dump")
+	if err == nil {
+		t.Error("getDataFromFile(): must return error, returned nil")
+		return
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Service must not write to the global logger, output = %s", buf)
+		return
+	}
+}
+
+func TestStdLogger_Log_success(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewStdLogger(log.New(buf, "", 0), LevelInfo)
+
+	logger.Log(LevelDebug, "skipped")
+	logger.Log(LevelError, "can't export", Op("export"), Path("/tmp/my data"), Count(2))
+
+	want := `level=ERROR msg="can't export" operation=export path="/tmp/my data" count=2`
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Log(): got %s, want %s", buf, want)
+		return
+	}
+}
@@ -0,0 +1,301 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrMemberNotFound - phone is not a member of the account
+var ErrMemberNotFound = errors.New("Member not found")
+
+// ErrMemberExists - phone is already a member or the owner of the account
+var ErrMemberExists = errors.New("Member already exists")
+
+// ErrNotAccountOwner - operation is allowed for the account owner only
+var ErrNotAccountOwner = errors.New("Operation is allowed for the account owner only")
+
+// ErrCategoryNotAllowed - member can't pay in the category
+var ErrCategoryNotAllowed = errors.New("Category is not allowed for the member")
+
+// AddMember lets the phone spend from the account within member limits, owner is the
+// phone of the account and must confirm the change
+func (s *Service) AddMember(owner types.Phone, member types.Member) (*types.Member, error) {
+	account, err := s.ownedAccount(owner, member.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member.PerPayment < 0 || member.Daily < 0 || member.Monthly < 0 {
+		return nil, ErrInvalidSpendingLimit
+	}
+
+	phone, err := s.phoneTable().Normalize(string(member.Phone))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.FindMember(account.ID, phone)
+	if err == nil || phone == account.Phone {
+		return nil, ErrMemberExists
+	}
+
+	member.ID = s.newID()
+	member.Phone = phone
+	member.Categories = append([]types.PaymentCategory(nil), member.Categories...)
+	member.AddedAt = s.now().Unix()
+
+	s.members = append(s.members, &member)
+	s.logger().Log(LevelInfo, "member added", Op("member"), AccountID(account.ID), Field{ Key: "member", Value: member.ID })
+
+	return &member, nil
+}
+
+// RemoveMember stops the phone from spending, payments it made keep its phone
+func (s *Service) RemoveMember(owner types.Phone, accountID int64, phone types.Phone) error {
+	account, err := s.ownedAccount(owner, accountID)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.FindMember(account.ID, phone)
+	if err != nil {
+		return err
+	}
+
+	for i, value := range s.members {
+		if value == member {
+			s.members = append(s.members[:i], s.members[i + 1:]...)
+			break
+		}
+	}
+
+	s.logger().Log(LevelInfo, "member removed", Op("member"), AccountID(accountID), Field{ Key: "member", Value: member.ID })
+	return nil
+}
+
+// FindMember returns member of the account by phone in any accepted format
+func (s *Service) FindMember(accountID int64, phone types.Phone) (*types.Member, error) {
+	normalized, err := s.phoneTable().Normalize(string(phone))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range s.members {
+		if member.AccountID == accountID && member.Phone == normalized {
+			return member, nil
+		}
+	}
+
+	return nil, ErrMemberNotFound
+}
+
+// FindMembers returns members of the account
+func (s *Service) FindMembers(accountID int64) []*types.Member {
+	var members []*types.Member
+	for _, member := range s.members {
+		if member.AccountID == accountID {
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// PayAsMember pays from the account on behalf of the member, member limits are
+// checked before account limits, periods are calendar day and month in UTC
+func (s *Service) PayAsMember(accountID int64, phone types.Phone, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	member, err := s.FindMember(accountID, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(member.Categories) > 0 && !containsCategory(member.Categories, category) {
+		return nil, ErrCategoryNotAllowed
+	}
+
+	if member.PerPayment > 0 && amount > member.PerPayment {
+		return nil, &LimitError{ Limit: LimitMemberPerPayment, Max: member.PerPayment, Remaining: member.PerPayment }
+	}
+
+	day, _, month := periodStarts(s.now())
+	periods := []struct {
+		name	string
+		max		types.Money
+		since	int64
+	} {
+		{ name: LimitMemberDaily,	max: member.Daily,		since: day.Unix() },
+		{ name: LimitMemberMonthly,	max: member.Monthly,	since: month.Unix() },
+	}
+
+	for _, period := range periods {
+		if period.max == 0 {
+			continue
+		}
+
+		spent, err := s.memberSpentSince(member, period.since)
+		if err != nil {
+			return nil, err
+		}
+
+		err = exceeds(period.name, "", period.max, spent, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payment, err := s.Pay(accountID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.InitiatedBy = member.Phone
+	if fee := s.feeOf(payment.ID); fee != nil {
+		fee.InitiatedBy = member.Phone
+	}
+
+	return payment, nil
+}
+
+// FindPaymentsByMember returns payments made by the member, empty phone returns payments of the owner
+func (s *Service) FindPaymentsByMember(accountID int64, phone types.Phone) ([]*types.Payment, error) {
+	var initiator types.Phone
+	if phone != "" {
+		normalized, err := s.phoneTable().Normalize(string(phone))
+		if err != nil {
+			return nil, err
+		}
+		initiator = normalized
+	}
+
+	var payments []*types.Payment
+	for _, payment := range s.payments {
+		if payment.AccountID == accountID && payment.InitiatedBy == initiator {
+			payments = append(payments, payment)
+		}
+	}
+
+	return payments, nil
+}
+
+// ownedAccount returns open account if the phone is its owner
+func (s *Service) ownedAccount(owner types.Phone, accountID int64) (*types.Account, error) {
+	account, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	main, err := s.mainPocket(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := s.phoneTable().Normalize(string(owner))
+	if err != nil || normalized != main.Phone {
+		return nil, ErrNotAccountOwner
+	}
+
+	return account, nil
+}
+
+// memberSpentSince sums charged payments less refunds made by the member, ErrOverflow if it doesn't fit
+func (s *Service) memberSpentSince(member *types.Member, since int64) (types.Money, error) {
+	spent := money.Sum{}
+	for _, payment := range s.payments {
+		if payment.AccountID != member.AccountID || payment.InitiatedBy != member.Phone {
+			continue
+		}
+
+		if payment.CreatedAt < since || !charged(payment.Status) {
+			continue
+		}
+
+		spent.Add(payment.Amount)
+		spent.Add(-s.refunded(payment.ID))
+	}
+
+	return spent.Money()
+}
+
+func containsCategory(categories []types.PaymentCategory, category types.PaymentCategory) bool {
+	for _, value := range categories {
+		if value == category {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) parseMemberToString(member *types.Member) string {
+	categories := make([]string, 0, len(member.Categories))
+	for _, category := range member.Categories {
+		categories = append(categories, string(category))
+	}
+
+	parsed := member.ID + ";"
+	parsed += strconv.FormatInt(member.AccountID, 10) + ";"
+	parsed += string(member.Phone) + ";"
+	parsed += strconv.FormatInt(int64(member.PerPayment), 10) + ";"
+	parsed += strconv.FormatInt(int64(member.Daily), 10) + ";"
+	parsed += strconv.FormatInt(int64(member.Monthly), 10) + ";"
+	parsed += strings.Join(categories, ",") + ";"
+	parsed += strconv.FormatInt(member.AddedAt, 10) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToMembers(data string) []*types.Member {
+	var members []*types.Member
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 8 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[1], 10, 64)
+		perPayment, _	:= strconv.ParseInt(item[3], 10, 64)
+		daily, _		:= strconv.ParseInt(item[4], 10, 64)
+		monthly, _		:= strconv.ParseInt(item[5], 10, 64)
+		addedAt, _		:= strconv.ParseInt(item[7], 10, 64)
+
+		member := &types.Member {
+			ID:			item[0],
+			AccountID:	accountID,
+			Phone:		types.Phone(item[2]),
+			PerPayment:	types.Money(perPayment),
+			Daily:		types.Money(daily),
+			Monthly:	types.Money(monthly),
+			AddedAt:	addedAt,
+		}
+
+		for _, category := range strings.Split(item[6], ",") {
+			if category != "" {
+				member.Categories = append(member.Categories, types.PaymentCategory(category))
+			}
+		}
+
+		members = append(members, member)
+	}
+
+	return members
+}
+
+func (s *Service) containsMember(item *types.Member, items []*types.Member) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
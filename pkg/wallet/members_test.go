@@ -0,0 +1,222 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addMember(account *types.Account) (*types.Member, error) {
+	member, err := s.AddMember(account.Phone, types.Member {
+		AccountID:	account.ID,
+		Phone:		"+992 93 745 29 46",
+		PerPayment:	500,
+		Daily:		800,
+		Categories:	[]types.PaymentCategory{ "food", "books" },
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't add member, error = %v", err)
+	}
+
+	return member, nil
+}
+
+func TestService_AddMember_fail(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992937452945", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.addMember(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		owner	types.Phone
+		member	types.Member
+		want	error
+	} {
+		{ name: "not owner",	owner: "+992937452946",	member: types.Member{ AccountID: account.ID, Phone: "+992937452947" },	want: ErrNotAccountOwner },
+		{ name: "no account",	owner: "+992937452945",	member: types.Member{ AccountID: 100, Phone: "+992937452947" },			want: ErrAccountNotFound },
+		{ name: "member",		owner: "+992937452945",	member: types.Member{ AccountID: account.ID, Phone: "937452946" },		want: ErrMemberExists },
+		{ name: "owner",		owner: "+992937452945",	member: types.Member{ AccountID: account.ID, Phone: "+992937452945" },	want: ErrMemberExists },
+		{ name: "bad phone",	owner: "+992937452945",	member: types.Member{ AccountID: account.ID, Phone: "12" },				want: ErrInvalidPhone },
+		{ name: "negative",		owner: "+992937452945",	member: types.Member{ AccountID: account.ID, Phone: "+992937452947", Daily: -1 },	want: ErrInvalidSpendingLimit },
+	}
+
+	for _, test := range tests {
+		_, err := s.AddMember(test.owner, test.member)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: AddMember(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestService_PayAsMember_limits(t *testing.T) {
+	s := newTestService()
+	clock := NewManualClock(time.Date(2021, 6, 10, 9, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, err := s.addAccountWithBalance("+992937452945", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	member, err := s.addMember(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayAsMember(account.ID, "+992937452946", 500, "food")
+	if err != nil {
+		t.Errorf("PayAsMember(): error = %v", err)
+		return
+	}
+
+	if payment.InitiatedBy != member.Phone || account.Balance != 9_500 {
+		t.Errorf("PayAsMember(): wrong payment = %+v, balance = %v", payment, account.Balance)
+		return
+	}
+
+	_, err = s.PayAsMember(account.ID, "+992937452946", 100, "games")
+	if err != ErrCategoryNotAllowed {
+		t.Errorf("PayAsMember(): must return ErrCategoryNotAllowed, but error = %v", err)
+		return
+	}
+
+	_, err = s.PayAsMember(account.ID, "+992937452946", 501, "food")
+	assertLimitError(t, err, LimitMemberPerPayment, 500)
+
+	_, err = s.PayAsMember(account.ID, "+992937452946", 400, "books")
+	assertLimitError(t, err, LimitMemberDaily, 300)
+
+	// payments of the owner don't count against the member
+	_, err = s.Pay(account.ID, 5_000, "food")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	clock.Advance(24 * time.Hour)
+	_, err = s.PayAsMember(account.ID, "+992937452946", 400, "books")
+	if err != nil {
+		t.Errorf("PayAsMember(): daily limit must be reset, error = %v", err)
+		return
+	}
+
+	_, err = s.PayAsMember(account.ID, "+992937452947", 100, "food")
+	if err != ErrMemberNotFound {
+		t.Errorf("PayAsMember(): must return ErrMemberNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_FindPaymentsByMember_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992937452945", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.addMember(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	byMember, err := s.PayAsMember(account.ID, "+992937452946", 100, "food")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	byOwner, err := s.Pay(account.ID, 200, "food")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.RemoveMember("+992937452945", account.ID, "+992937452946")
+	if err != nil {
+		t.Errorf("RemoveMember(): error = %v", err)
+		return
+	}
+
+	_, err = s.PayAsMember(account.ID, "+992937452946", 100, "food")
+	if err != ErrMemberNotFound {
+		t.Errorf("PayAsMember(): removed member must not pay, error = %v", err)
+		return
+	}
+
+	payments, err := s.FindPaymentsByMember(account.ID, "937452946")
+	if err != nil || len(payments) != 1 || payments[0] != byMember {
+		t.Errorf("FindPaymentsByMember(): wrong payments = %v, error = %v", payments, err)
+		return
+	}
+
+	payments, err = s.FindPaymentsByMember(account.ID, "")
+	if err != nil || len(payments) != 1 || payments[0] != byOwner {
+		t.Errorf("FindPaymentsByMember(): wrong owner payments = %v, error = %v", payments, err)
+		return
+	}
+}
+
+func TestService_Export_members(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992937452945", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	member, err := s.addMember(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayAsMember(account.ID, "+992937452946", 100, "food")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	members := imported.FindMembers(account.ID)
+	if len(members) != 1 || members[0].Phone != member.Phone || members[0].Daily != 800 || len(members[0].Categories) != 2 {
+		t.Errorf("importFrom(): wrong members = %+v", members)
+		return
+	}
+
+	restored, err := imported.FindPaymentByID(payment.ID)
+	if err != nil || restored.InitiatedBy != member.Phone || restored.Params != nil {
+		t.Errorf("importFrom(): wrong payment = %+v, error = %v", restored, err)
+		return
+	}
+}
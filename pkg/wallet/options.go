@@ -0,0 +1,282 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/exchange"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/operator"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/phone"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrInvalidOption - service option has invalid value
+var ErrInvalidOption = errors.New("Invalid service option")
+
+// DefaultCurrency - currency used when none is configured
+const DefaultCurrency types.Currency = "TJS"
+
+// Limits - service wide limits of accounts in the currency, zero means no limit
+type Limits struct {
+	Currency	types.Currency	// empty means the default currency of the service
+	MaxPayment	types.Money
+	MaxDeposit	types.Money
+	MaxBalance	types.Money
+}
+
+// Option configures service created by NewService
+type Option func(s *Service) error
+
+// NewService creates service with the given options, zero value Service is still usable
+func NewService(options ...Option) (*Service, error) {
+	s := &Service{}
+	for _, option := range options {
+		err := option(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// WithLogger sets logger, records are discarded by default
+func WithLogger(logger Logger) Option {
+	return func(s *Service) error {
+		if logger == nil {
+			return fmt.Errorf("%w: logger is nil", ErrInvalidOption)
+		}
+
+		s.log = logger
+		return nil
+	}
+}
+
+// WithIDGenerator sets generator of payment and favorite ids, random UUIDs by default
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(s *Service) error {
+		if ids == nil {
+			return fmt.Errorf("%w: id generator is nil", ErrInvalidOption)
+		}
+
+		s.ids = ids
+		return nil
+	}
+}
+
+// WithClock sets clock used for timestamps, system time by default
+func WithClock(clock Clock) Option {
+	return func(s *Service) error {
+		if clock == nil {
+			return fmt.Errorf("%w: clock is nil", ErrInvalidOption)
+		}
+
+		s.clock = clock
+		return nil
+	}
+}
+
+// WithStorage sets storage used by Save and Load
+func WithStorage(storage Storage) Option {
+	return func(s *Service) error {
+		if storage == nil {
+			return fmt.Errorf("%w: storage is nil", ErrInvalidOption)
+		}
+
+		if s.storage != nil {
+			return fmt.Errorf("%w: storage is already configured", ErrInvalidOption)
+		}
+
+		s.storage = storage
+		return nil
+	}
+}
+
+// WithExportDir keeps dumps of Save and Load in the directory
+func WithExportDir(dir string) Option {
+	return func(s *Service) error {
+		if dir == "" {
+			return fmt.Errorf("%w: export directory is empty", ErrInvalidOption)
+		}
+
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("%w: export directory %q: %v", ErrInvalidOption, dir, err)
+		}
+
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("%w: export directory %q is a file", ErrInvalidOption, dir)
+		}
+
+		return WithStorage(NewDirStorage(path))(s)
+	}
+}
+
+// WithDefaultCurrency sets currency of new accounts, TJS by default
+func WithDefaultCurrency(currency types.Currency) Option {
+	return func(s *Service) error {
+		_, err := money.Lookup(currency)
+		if err != nil {
+			return fmt.Errorf("%w: currency %q must be ISO 4217 code like TJS", ErrInvalidOption, currency)
+		}
+
+		s.currency = currency
+		return nil
+	}
+}
+
+// WithLimits sets service wide limits of the currency, give it once per currency,
+// accounts in currencies without limits are not limited
+func WithLimits(limits Limits) Option {
+	return func(s *Service) error {
+		if limits.Currency != "" {
+			_, err := money.Lookup(limits.Currency)
+			if err != nil {
+				return fmt.Errorf("%w: limits currency %q must be ISO 4217 code like TJS", ErrInvalidOption, limits.Currency)
+			}
+		}
+
+		if limits.MaxPayment < 0 || limits.MaxDeposit < 0 || limits.MaxBalance < 0 {
+			return fmt.Errorf("%w: limits must not be negative", ErrInvalidOption)
+		}
+
+		if limits.MaxBalance > 0 && limits.MaxDeposit > limits.MaxBalance {
+			return fmt.Errorf("%w: max deposit %d is more than max balance %d", ErrInvalidOption, limits.MaxDeposit, limits.MaxBalance)
+		}
+
+		if s.limits == nil {
+			s.limits = make(map[types.Currency]Limits)
+		}
+
+		s.limits[limits.Currency] = limits
+		return nil
+	}
+}
+
+// WithHoldTTL sets time after which not captured holds expire, DefaultHoldTTL by default
+func WithHoldTTL(ttl time.Duration) Option {
+	return func(s *Service) error {
+		if ttl <= 0 {
+			return fmt.Errorf("%w: hold ttl must be positive", ErrInvalidOption)
+		}
+
+		s.holdExpiry = ttl
+		return nil
+	}
+}
+
+// WithSchedulePolicy sets retries and catch up of scheduled payments, DefaultSchedulePolicy by default
+func WithSchedulePolicy(policy SchedulePolicy) Option {
+	return func(s *Service) error {
+		if policy.Grace < 0 || policy.MaxRetries < 0 || policy.Backoff < 0 {
+			return fmt.Errorf("%w: schedule policy must not be negative", ErrInvalidOption)
+		}
+
+		if policy.MaxRetries > MaxScheduleRetries {
+			return fmt.Errorf("%w: schedule policy allows at most %d retries", ErrInvalidOption, MaxScheduleRetries)
+		}
+
+		if policy.CatchUp < CatchUpLatest || policy.CatchUp > CatchUpNone {
+			return fmt.Errorf("%w: unknown catch up policy %d", ErrInvalidOption, policy.CatchUp)
+		}
+
+		s.policy = &policy
+		return nil
+	}
+}
+
+// WithPhoneTable sets countries accepted by RegisterAccount, phone.Default by default
+func WithPhoneTable(table *phone.Table) Option {
+	return func(s *Service) error {
+		if table == nil {
+			return fmt.Errorf("%w: phone table is nil", ErrInvalidOption)
+		}
+
+		s.phones = table
+		return nil
+	}
+}
+
+// WithPhoneGrace sets how long old phones of the account are still found, DefaultPhoneGrace by default
+func WithPhoneGrace(grace time.Duration) Option {
+	return func(s *Service) error {
+		if grace <= 0 {
+			return fmt.Errorf("%w: phone grace period must be positive", ErrInvalidOption)
+		}
+
+		s.phoneGrace = grace
+		return nil
+	}
+}
+
+// WithOperators sets registry used by TopUp, operator.Default by default
+func WithOperators(registry *operator.Registry) Option {
+	return func(s *Service) error {
+		if registry == nil {
+			return fmt.Errorf("%w: operator registry is nil", ErrInvalidOption)
+		}
+
+		s.operators = registry
+		return nil
+	}
+}
+
+// WithRates sets exchange rates used by QuoteExchange, exchanges are refused by default
+func WithRates(table *exchange.Table) Option {
+	return func(s *Service) error {
+		if table == nil {
+			return fmt.Errorf("%w: exchange rate table is nil", ErrInvalidOption)
+		}
+
+		s.rates = table
+		return nil
+	}
+}
+
+// WithQuoteTTL sets time during which quoted exchange may be executed, DefaultQuoteTTL by default
+func WithQuoteTTL(ttl time.Duration) Option {
+	return func(s *Service) error {
+		if ttl <= 0 {
+			return fmt.Errorf("%w: quote ttl must be positive", ErrInvalidOption)
+		}
+
+		s.quoteExpiry = ttl
+		return nil
+	}
+}
+
+// WithFees sets fee schedule of payments, transfers and exchanges, they are free by default
+func WithFees(schedule *fees.Schedule) Option {
+	return func(s *Service) error {
+		if schedule == nil {
+			return fmt.Errorf("%w: fee schedule is nil", ErrInvalidOption)
+		}
+
+		s.fees = schedule
+		return nil
+	}
+}
+
+// Currency returns default currency of the service
+func (s *Service) Currency() types.Currency {
+	if s.currency == "" {
+		return DefaultCurrency
+	}
+
+	return s.currency
+}
+
+// limitsOf returns service wide limits of accounts in the currency
+func (s *Service) limitsOf(currency types.Currency) Limits {
+	limits, ok := s.limits[currency]
+	if !ok && currency == s.Currency() {
+		limits = s.limits[""]
+	}
+
+	return limits
+}
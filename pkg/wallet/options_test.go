@@ -0,0 +1,259 @@
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestNewService_success(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewService(
+		WithLogger(NopLogger{}),
+		WithIDGenerator(&SequentialGenerator{}),
+		WithClock(SystemClock{}),
+		WithExportDir(dir),
+		WithDefaultCurrency("USD"),
+		WithLimits(Limits{ MaxPayment: 100, MaxDeposit: 1_000, MaxBalance: 10_000 }),
+	)
+	if err != nil {
+		t.Errorf("NewService(): error = %v", err)
+		return
+	}
+
+	if s.Currency() != "USD" {
+		t.Errorf("NewService(): wrong currency = %v", s.Currency())
+		return
+	}
+
+	if (&Service{}).Currency() != DefaultCurrency {
+		t.Error("Currency(): zero value must use default currency")
+		return
+	}
+}
+
+func TestNewService_invalidOptions(t *testing.T) {
+	file, err := ioutil.TempFile("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	tests := []struct {
+		name	string
+		options	[]Option
+	} {
+		{ name: "nil logger",			options: []Option{ WithLogger(nil) } },
+		{ name: "nil ids",				options: []Option{ WithIDGenerator(nil) } },
+		{ name: "nil clock",			options: []Option{ WithClock(nil) } },
+		{ name: "nil storage",			options: []Option{ WithStorage(nil) } },
+		{ name: "two storages",			options: []Option{ WithStorage(&MemoryStorage{}), WithExportDir(".") } },
+		{ name: "empty dir",			options: []Option{ WithExportDir("") } },
+		{ name: "dir is file",			options: []Option{ WithExportDir(file.Name()) } },
+		{ name: "lowercase currency",	options: []Option{ WithDefaultCurrency("usd") } },
+		{ name: "long currency",		options: []Option{ WithDefaultCurrency("USDT") } },
+		{ name: "unknown currency",		options: []Option{ WithDefaultCurrency("XYZ") } },
+		{ name: "nil rates",			options: []Option{ WithRates(nil) } },
+		{ name: "zero quote ttl",		options: []Option{ WithQuoteTTL(0) } },
+		{ name: "many retries",			options: []Option{ WithSchedulePolicy(SchedulePolicy{ MaxRetries: MaxScheduleRetries + 1 }) } },
+		{ name: "nil fees",				options: []Option{ WithFees(nil) } },
+		{ name: "negative limit",		options: []Option{ WithLimits(Limits{ MaxPayment: -1 }) } },
+		{ name: "limits currency",		options: []Option{ WithLimits(Limits{ Currency: "XYZ" }) } },
+		{ name: "deposit over balance",	options: []Option{ WithLimits(Limits{ MaxDeposit: 10, MaxBalance: 5 }) } },
+	}
+
+	for _, test := range tests {
+		s, err := NewService(test.options...)
+		if !errors.Is(err, ErrInvalidOption) || s != nil {
+			t.Errorf("NewService(%s): must return ErrInvalidOption, but error = %v", test.name, err)
+		}
+	}
+}
+
+func TestService_Limits_exceeded(t *testing.T) {
+	svc, err := NewService(WithLimits(Limits{ MaxPayment: 100, MaxDeposit: 1_000, MaxBalance: 1_500 }))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := &testService{ Service: svc }
+	account, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 1_001)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Deposit(): must return ErrLimitExceeded, but error = %v", err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 501)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Deposit(): balance limit, must return ErrLimitExceeded, but error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 101, "auto")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, but error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func TestService_Limits_perCurrency(t *testing.T) {
+	svc, err := NewService(WithLimits(Limits{ MaxPayment: 100 }), WithLimits(Limits{ Currency: "USD", MaxPayment: 10, MaxDeposit: 500 }))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := &testService{ Service: svc }
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dollars, err := s.addPocketWithBalance(main.ID, "dollars", "USD", 500)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	euros, err := s.addPocketWithBalance(main.ID, "euros", "EUR", 1_000)
+	if err != nil {
+		t.Errorf("Deposit(): currency without limits must not be limited, error = %v", err)
+		return
+	}
+
+	err = s.Deposit(dollars.ID, 501)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Deposit(): must return ErrLimitExceeded, but error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(dollars.ID, 11, "auto")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must use limits of the account currency, but error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(main.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(euros.ID, 500, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func TestService_SaveLoad_success(t *testing.T) {
+	storage := &MemoryStorage{}
+	svc, err := NewService(WithStorage(storage))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := &testService{ Service: svc }
+	_, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Save()
+	if err != nil {
+		t.Errorf("Save(): error = %v", err)
+		return
+	}
+
+	restored, err := NewService(WithStorage(storage))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = restored.Load()
+	if err != nil {
+		t.Errorf("Load(): error = %v", err)
+		return
+	}
+
+	payment, err := restored.FindPaymentByID(payments[0].ID)
+	if err != nil {
+		t.Errorf("Load(): payment is not restored, error = %v", err)
+		return
+	}
+
+	if payment.Amount != payments[0].Amount || payment.Status != types.PaymentStatusInProgress {
+		t.Errorf("Load(): wrong payment restored = %v", payment)
+		return
+	}
+
+	if len(restored.GetAccounts()) != 1 || len(restored.GetFavorites()) != 1 {
+		t.Error("Load(): accounts and favorites must be restored")
+		return
+	}
+}
+
+func TestService_Save_notConfigured(t *testing.T) {
+	s := &Service{}
+
+	if s.Save() != ErrStorageNotConfigured || s.Load() != ErrStorageNotConfigured {
+		t.Error("Save(), Load(): must return ErrStorageNotConfigured")
+	}
+}
+
+func TestDirStorage_ReadWrite_success(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	storage := NewDirStorage(filepath.Join(dir, "nested"))
+
+	_, err = storage.Read(accountsDump)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Read(): must return os.ErrNotExist, but error = %v", err)
+		return
+	}
+
+	err = storage.Write(accountsDump, []byte("1;+992937452945;0\n"))
+	if err != nil {
+		t.Errorf("Write(): error = %v", err)
+		return
+	}
+
+	data, err := storage.Read(accountsDump)
+	if err != nil || string(data) != "1;+992937452945;0\n" {
+		t.Errorf("Read(): wrong data = %s, error = %v", data, err)
+		return
+	}
+}
@@ -0,0 +1,236 @@
+package wallet
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/phone"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrInvalidPhone - phone number is not valid E.164 number of a known country
+var ErrInvalidPhone = phone.ErrInvalidPhone
+
+// DefaultPhoneGrace - time during which old phone of the account is still found
+const DefaultPhoneGrace = 30 * 24 * time.Hour
+
+// PhoneChange - phone of the account rewritten by MigratePhones
+type PhoneChange struct {
+	AccountID	int64
+	From		types.Phone
+	To			types.Phone
+}
+
+// PhoneCollision - accounts whose phones have the same normalized form
+type PhoneCollision struct {
+	Phone		types.Phone
+	AccountIDs	[]int64
+}
+
+// PhoneMigration - report of MigratePhones
+type PhoneMigration struct {
+	Changed		[]PhoneChange
+	Invalid		[]int64				// accounts with phones that can't be normalized, kept as is
+	Collisions	[]PhoneCollision	// kept as is until resolved manually
+}
+
+// FindAccountByPhone returns account by the phone in any accepted format
+func (s *Service) FindAccountByPhone(number types.Phone) (*types.Account, error) {
+	normalized, err := s.phoneTable().Normalize(string(number))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range s.accounts {
+		if account.Phone == normalized {
+			return account, nil
+		}
+	}
+
+	// phones imported before normalization
+	for _, account := range s.accounts {
+		saved, err := s.phoneTable().Normalize(string(account.Phone))
+		if err == nil && saved == normalized {
+			return account, nil
+		}
+	}
+
+	// old phones during grace period, the latest change wins
+	since := s.now().Add(-s.phoneGracePeriod()).Unix()
+	for i := len(s.phoneHistory) - 1; i >= 0; i-- {
+		record := s.phoneHistory[i]
+		if record.Phone == normalized && record.ChangedAt > since {
+			return s.FindAccountByID(record.AccountID)
+		}
+	}
+
+	return nil, ErrAccountNotFound
+}
+
+// ChangePhone replaces phone of the account, the old phone is kept in history and
+// still finds the account during grace period, so it can't be taken by others until then
+func (s *Service) ChangePhone(accountID int64, number types.Phone) (*types.Account, error) {
+	account, err := s.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.CustomerID != 0 {
+		return nil, ErrMainPocketRequired
+	}
+
+	normalized, err := s.phoneTable().Normalize(string(number))
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Phone == normalized {
+		return account, nil
+	}
+
+	owner, err := s.FindAccountByPhone(normalized)
+	if err == nil && owner.ID != accountID {
+		return nil, ErrPhoneRegistered
+	}
+
+	record := &types.PhoneRecord {
+		ID:			s.newID(),
+		AccountID:	accountID,
+		Phone:		account.Phone,
+		ChangedAt:	s.now().Unix(),
+	}
+
+	s.phoneHistory = append(s.phoneHistory, record)
+	account.Phone = normalized
+	s.logger().Log(LevelInfo, "phone changed", Op("phone"), AccountID(accountID))
+
+	return account, nil
+}
+
+// PhoneHistory returns previous phones of the account, oldest first
+func (s *Service) PhoneHistory(accountID int64) []*types.PhoneRecord {
+	var records []*types.PhoneRecord
+	for _, record := range s.phoneHistory {
+		if record.AccountID == accountID {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// MigratePhones normalizes phones of imported accounts, accounts with invalid phones
+// and accounts which would share the same phone are reported and left unchanged,
+// dryRun only builds the report
+func (s *Service) MigratePhones(dryRun bool) *PhoneMigration {
+	migration := &PhoneMigration{}
+
+	owners := make(map[types.Phone][]*types.Account)
+	var phones []types.Phone
+	for _, account := range s.accounts {
+		if account.CustomerID != 0 {
+			continue	// pockets have no phone
+		}
+
+		normalized, err := s.phoneTable().Normalize(string(account.Phone))
+		if err != nil {
+			migration.Invalid = append(migration.Invalid, account.ID)
+			continue
+		}
+
+		if _, ok := owners[normalized]; !ok {
+			phones = append(phones, normalized)
+		}
+		owners[normalized] = append(owners[normalized], account)
+	}
+
+	for _, normalized := range phones {
+		accounts := owners[normalized]
+		if len(accounts) > 1 {
+			collision := PhoneCollision{ Phone: normalized }
+			for _, account := range accounts {
+				collision.AccountIDs = append(collision.AccountIDs, account.ID)
+			}
+			sort.Slice(collision.AccountIDs, func(i, j int) bool { return collision.AccountIDs[i] < collision.AccountIDs[j] })
+
+			migration.Collisions = append(migration.Collisions, collision)
+			continue
+		}
+
+		account := accounts[0]
+		if account.Phone == normalized {
+			continue
+		}
+
+		migration.Changed = append(migration.Changed, PhoneChange{ AccountID: account.ID, From: account.Phone, To: normalized })
+		if !dryRun {
+			account.Phone = normalized
+		}
+	}
+
+	s.logger().Log(LevelInfo, "phones migrated", Op("migrate"), Count(len(migration.Changed)), Field{ Key: "collisions", Value: len(migration.Collisions) })
+	return migration
+}
+
+func (s *Service) phoneTable() *phone.Table {
+	if s.phones == nil {
+		return phone.Default
+	}
+
+	return s.phones
+}
+
+func (s *Service) phoneGracePeriod() time.Duration {
+	if s.phoneGrace == 0 {
+		return DefaultPhoneGrace
+	}
+
+	return s.phoneGrace
+}
+
+func (s *Service) parsePhoneRecordToString(record *types.PhoneRecord) string {
+	parsed := record.ID + ";"
+	parsed += strconv.FormatInt(record.AccountID, 10) + ";"
+	parsed += string(record.Phone) + ";"
+	parsed += strconv.FormatInt(record.ChangedAt, 10) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToPhoneRecords(data string) []*types.PhoneRecord {
+	var records []*types.PhoneRecord
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 4 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[1], 10, 64)
+		changedAt, _	:= strconv.ParseInt(item[3], 10, 64)
+
+		record := &types.PhoneRecord {
+			ID:			item[0],
+			AccountID:	accountID,
+			Phone:		types.Phone(item[2]),
+			ChangedAt:	changedAt,
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func (s *Service) containsPhoneRecord(item *types.PhoneRecord, items []*types.PhoneRecord) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
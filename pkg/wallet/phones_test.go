@@ -0,0 +1,218 @@
+package wallet
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/phone"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_RegisterAccount_normalized(t *testing.T) {
+	s := &Service{}
+
+	account, err := s.RegisterAccount("+992 93 745 29 45")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	if account.Phone != "+992937452945" {
+		t.Errorf("RegisterAccount(): phone must be normalized, got %v", account.Phone)
+		return
+	}
+
+	for _, number := range []types.Phone{ "+992937452945", "992937452945", "937452945" } {
+		_, err = s.RegisterAccount(number)
+		if err != ErrPhoneRegistered {
+			t.Errorf("RegisterAccount(%q): must return ErrPhoneRegistered, but error = %v", number, err)
+		}
+	}
+
+	for _, number := range []types.Phone{ "", "+992", "+7 912 345 67 89" } {
+		_, err = s.RegisterAccount(number)
+		if !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("RegisterAccount(%q): must return ErrInvalidPhone, but error = %v", number, err)
+		}
+	}
+}
+
+func TestService_RegisterAccount_phoneTable(t *testing.T) {
+	russia := phone.Country{ Name: "RU", Code: "7", Lengths: []int{ 10 }, Trunk: "8" }
+	table, err := phone.NewTable(phone.Tajikistan, russia)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewService(WithPhoneTable(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := s.RegisterAccount("+7 (912) 345-67-89")
+	if err != nil || account.Phone != "+79123456789" {
+		t.Errorf("RegisterAccount(): wrong account = %v, error = %v", account, err)
+		return
+	}
+
+	found, err := s.FindAccountByPhone("0079123456789")
+	if err != nil || found != account {
+		t.Errorf("FindAccountByPhone(): wrong account = %v, error = %v", found, err)
+		return
+	}
+}
+
+func TestService_MigratePhones_collisions(t *testing.T) {
+	storage := &MemoryStorage{}
+	accounts := "1;+992937452945;100\n2;992 93 745 29 46;0\n3;937452945;50\n4;unknown;0\n5;+992937452947;0\n"
+	err := storage.Write(accountsDump, []byte(accounts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Service{}
+	err = s.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	found, err := s.FindAccountByPhone("+992937452946")
+	if err != nil || found.ID != 2 {
+		t.Errorf("FindAccountByPhone(): phones before migration must be found, account = %v, error = %v", found, err)
+		return
+	}
+
+	dry := s.MigratePhones(true)
+	if len(dry.Changed) != 1 || found.Phone != "992 93 745 29 46" {
+		t.Errorf("MigratePhones(): dry run must not change phones, report = %+v", dry)
+		return
+	}
+
+	migration := s.MigratePhones(false)
+	want := &PhoneMigration {
+		Changed:	[]PhoneChange{ { AccountID: 2, From: "992 93 745 29 46", To: "+992937452946" } },
+		Invalid:	[]int64{ 4 },
+		Collisions:	[]PhoneCollision{ { Phone: "+992937452945", AccountIDs: []int64{ 1, 3 } } },
+	}
+
+	if !reflect.DeepEqual(migration, want) {
+		t.Errorf("MigratePhones(): got %+v, want %+v", migration, want)
+		return
+	}
+
+	if found.Phone != "+992937452946" {
+		t.Errorf("MigratePhones(): phone is not normalized = %v", found.Phone)
+		return
+	}
+
+	account, err := s.FindAccountByID(3)
+	if err != nil || account.Phone != "937452945" {
+		t.Errorf("MigratePhones(): colliding phones must be kept, account = %v, error = %v", account, err)
+		return
+	}
+}
+
+func TestService_ChangePhone_grace(t *testing.T) {
+	clock := NewManualClock(time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC))
+	s, err := NewService(WithClock(clock), WithIDGenerator(&SequentialGenerator{}), WithPhoneGrace(24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := s.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.ChangePhone(account.ID, "+992937452946")
+	if err != ErrPhoneRegistered {
+		t.Errorf("ChangePhone(): must return ErrPhoneRegistered, but error = %v", err)
+		return
+	}
+
+	_, err = s.ChangePhone(account.ID, "93 745 29 47")
+	if err != nil {
+		t.Errorf("ChangePhone(): error = %v", err)
+		return
+	}
+
+	history := s.PhoneHistory(account.ID)
+	if account.Phone != "+992937452947" || len(history) != 1 || history[0].Phone != "+992937452945" || history[0].ChangedAt != clock.Now().Unix() {
+		t.Errorf("ChangePhone(): wrong account = %v, history = %v", account, history)
+		return
+	}
+
+	found, err := s.FindAccountByPhone("+992937452945")
+	if err != nil || found != account {
+		t.Errorf("FindAccountByPhone(): old phone must be found during grace period, account = %v, error = %v", found, err)
+		return
+	}
+
+	_, err = s.ChangePhone(other.ID, "+992937452945")
+	if err != ErrPhoneRegistered {
+		t.Errorf("ChangePhone(): old phone is reserved during grace period, error = %v", err)
+		return
+	}
+
+	clock.Advance(24 * time.Hour)
+	_, err = s.FindAccountByPhone("+992937452945")
+	if err != ErrAccountNotFound {
+		t.Errorf("FindAccountByPhone(): must return ErrAccountNotFound after grace period, but error = %v", err)
+		return
+	}
+
+	_, err = s.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Errorf("RegisterAccount(): old phone must be free after grace period, error = %v", err)
+		return
+	}
+}
+
+func TestService_Export_phoneHistory(t *testing.T) {
+	s, err := NewService(WithIDGenerator(&SequentialGenerator{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := s.RegisterAccount("+992937452945")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.ChangePhone(account.ID, "+992937452947")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(imported.PhoneHistory(account.ID), s.PhoneHistory(account.ID)) {
+		t.Errorf("importFrom(): got %v, want %v", imported.PhoneHistory(account.ID), s.PhoneHistory(account.ID))
+		return
+	}
+
+	found, err := imported.FindAccountByPhone("+992937452945")
+	if err != nil || found.ID != account.ID {
+		t.Errorf("FindAccountByPhone(): wrong account = %v, error = %v", found, err)
+		return
+	}
+}
@@ -0,0 +1,413 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrPocketNotFound - customer has no pocket with the name
+var ErrPocketNotFound = errors.New("Pocket not found")
+
+// ErrPocketNameTaken - customer already has pocket with the name
+var ErrPocketNameTaken = errors.New("Pocket with the given name already exists")
+
+// ErrInvalidPocketName - name is empty, reserved or has characters reserved by dumps
+var ErrInvalidPocketName = errors.New("Pocket name must not be empty, main or contain ';' and line breaks")
+
+// ErrNotSameCustomer - pockets belong to different customers
+var ErrNotSameCustomer = errors.New("Pockets belong to different customers")
+
+// ErrMainPocketRequired - operation is allowed for the main pocket only
+var ErrMainPocketRequired = errors.New("Operation is allowed for the main pocket only")
+
+// MainPocket - name of the account registered by phone
+const MainPocket = "main"
+
+// PocketMoveCategory - category of moves between pockets shown in pocket history
+const PocketMoveCategory types.PaymentCategory = "pocket_move"
+
+// PocketBalance - balance of one pocket
+type PocketBalance struct {
+	AccountID	int64
+	Pocket		string
+	Currency	types.Currency
+	Balance		types.Money
+	Available	types.Money
+}
+
+// CustomerBalance - balances of all pockets of the customer, Balance and Available sum
+// pockets in the currency of the main pocket and Balances sums every currency
+type CustomerBalance struct {
+	CustomerID	int64
+	Phone		types.Phone
+	Currency	types.Currency
+	Balance		types.Money
+	Available	types.Money
+	Balances	money.Totals
+	Pockets		[]PocketBalance
+}
+
+// OpenPocket opens new pocket of the customer owning the account, the pocket is an
+// account without phone and is found by the customer phone through its main pocket
+func (s *Service) OpenPocket(accountID int64, name string) (*types.Account, error) {
+	main, err := s.mainPocket(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.OpenPocketInCurrency(main.ID, name, AccountCurrency(main))
+}
+
+// OpenPocketInCurrency opens new pocket in the currency, so the customer may hold several currencies
+func (s *Service) OpenPocketInCurrency(accountID int64, name string, currency types.Currency) (*types.Account, error) {
+	_, err := money.Lookup(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	main, err := s.mainPocket(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(main, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(name) == "" || strings.EqualFold(name, MainPocket) || strings.ContainsAny(name, ";\r\n") {
+		return nil, ErrInvalidPocketName
+	}
+
+	_, err = s.FindPocket(main.ID, name)
+	if err == nil {
+		return nil, ErrPocketNameTaken
+	}
+
+	s.nextAccountID++
+	pocket := &types.Account {
+		ID:			s.nextAccountID,
+		Status:		types.AccountStatusActive,
+		CustomerID:	main.ID,
+		Pocket:		name,
+		Currency:	currency,
+	}
+
+	s.accounts = append(s.accounts, pocket)
+	s.logger().Log(LevelInfo, "pocket opened", Op("pocket"), AccountID(pocket.ID), Field{ Key: "customer", Value: main.ID })
+
+	return pocket, nil
+}
+
+// FindPocket returns pocket of the customer owning the account by name, main for the main pocket
+func (s *Service) FindPocket(accountID int64, name string) (*types.Account, error) {
+	main, err := s.mainPocket(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" || strings.EqualFold(name, MainPocket) {
+		return main, nil
+	}
+
+	for _, account := range s.accounts {
+		if account.CustomerID == main.ID && strings.EqualFold(account.Pocket, name) {
+			return account, nil
+		}
+	}
+
+	return nil, ErrPocketNotFound
+}
+
+// Pockets returns pockets of the customer owning the account, main pocket first
+func (s *Service) Pockets(accountID int64) ([]*types.Account, error) {
+	main, err := s.mainPocket(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	pockets := []*types.Account{ main }
+	for _, account := range s.accounts {
+		if account.CustomerID == main.ID {
+			pockets = append(pockets, account)
+		}
+	}
+
+	return pockets, nil
+}
+
+// FindCustomerBalance returns balances of pockets of the customer owning the account
+func (s *Service) FindCustomerBalance(accountID int64) (*CustomerBalance, error) {
+	pockets, err := s.Pockets(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	balance := &CustomerBalance {
+		CustomerID:	pockets[0].ID,
+		Phone:		pockets[0].Phone,
+		Currency:	AccountCurrency(pockets[0]),
+	}
+
+	balances := money.Sums{}
+	available := money.Sum{}
+	for _, pocket := range pockets {
+		if pocket.Status == types.AccountStatusClosed {
+			continue
+		}
+
+		currency := AccountCurrency(pocket)
+		if currency == balance.Currency {
+			available.Add(pocket.Available())
+		}
+
+		balances.Add(money.New(pocket.Balance, currency))
+		balance.Pockets = append(balance.Pockets, PocketBalance {
+			AccountID:	pocket.ID,
+			Pocket:		pocketName(pocket),
+			Currency:	currency,
+			Balance:	pocket.Balance,
+			Available:	pocket.Available(),
+		})
+	}
+
+	balance.Balances, err = balances.Totals()
+	if err != nil {
+		return nil, err
+	}
+
+	balance.Balance = balance.Balances[balance.Currency]
+	balance.Available, err = available.Money()
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// PayFromPocket pays from the pocket of the customer owning the account
+func (s *Service) PayFromPocket(accountID int64, pocket string, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	source, err := s.FindPocket(accountID, pocket)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Pay(source.ID, amount, category)
+}
+
+// MoveBetweenPockets moves money between pockets of the same customer and currency,
+// moves are not payments and are not checked against spending limits
+func (s *Service) MoveBetweenPockets(fromAccountID int64, toAccountID int64, amount types.Money) (*types.PocketMove, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.FindAccountByID(toAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	customerID := customerOf(from)
+	if customerID != customerOf(to) || fromAccountID == toAccountID {
+		return nil, ErrNotSameCustomer
+	}
+
+	if AccountCurrency(from) != AccountCurrency(to) {
+		return nil, ErrCurrencyMismatch
+	}
+
+	err = s.checkAccount(from, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(to, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+	if from.Available() < amount {
+		return nil, ErrNotEnoughBalance
+	}
+
+	fromBalance, err := from.Balance.Sub(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	toBalance, err := to.Balance.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	move := &types.PocketMove {
+		ID:				s.newID(),
+		CustomerID:		customerID,
+		FromAccountID:	fromAccountID,
+		ToAccountID:	toAccountID,
+		Amount:			amount,
+		CreatedAt:		s.now().Unix(),
+	}
+
+	from.Balance = fromBalance
+	to.Balance = toBalance
+	s.pocketMoves = append(s.pocketMoves, move)
+	s.logger().Log(LevelInfo, "pocket move", Op("move"), AccountID(fromAccountID), Field{ Key: "to", Value: toAccountID })
+
+	return move, nil
+}
+
+// FindPocketMoves returns moves from and to the pocket
+func (s *Service) FindPocketMoves(accountID int64) []*types.PocketMove {
+	var moves []*types.PocketMove
+	for _, move := range s.pocketMoves {
+		if move.FromAccountID == accountID || move.ToAccountID == accountID {
+			moves = append(moves, move)
+		}
+	}
+
+	return moves
+}
+
+// withPocketMoves adds moves from and to the pocket to its payments as payments of
+// PocketMoveCategory, params name both pockets, the result is ordered by time
+func (s *Service) withPocketMoves(accountID int64, payments []*types.Payment) []*types.Payment {
+	moves := s.FindPocketMoves(accountID)
+	if len(moves) == 0 {
+		return payments
+	}
+
+	currency := DefaultCurrency
+	account, err := s.FindAccountByID(accountID)
+	if err == nil {
+		currency = AccountCurrency(account)
+	}
+
+	for _, move := range moves {
+		payments = append(payments, &types.Payment {
+			ID:			move.ID,
+			AccountID:	accountID,
+			Amount:		move.Amount,
+			Category:	PocketMoveCategory,
+			Status:		types.PaymentStatusOk,
+			CreatedAt:	move.CreatedAt,
+			Params:		map[string]string {
+				"from":	strconv.FormatInt(move.FromAccountID, 10),
+				"to":	strconv.FormatInt(move.ToAccountID, 10),
+			},
+			Currency:	currency,
+		})
+	}
+
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].CreatedAt < payments[j].CreatedAt
+	})
+
+	return payments
+}
+
+// mainPocket returns main account of the customer owning the account
+func (s *Service) mainPocket(accountID int64) (*types.Account, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.CustomerID == 0 {
+		return account, nil
+	}
+
+	return s.FindAccountByID(account.CustomerID)
+}
+
+// openPockets reports whether the main account has pockets which are not closed
+func (s *Service) openPockets(account *types.Account) bool {
+	for _, pocket := range s.accounts {
+		if pocket.CustomerID == account.ID && pocket.Status != types.AccountStatusClosed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func customerOf(account *types.Account) int64 {
+	if account.CustomerID == 0 {
+		return account.ID
+	}
+
+	return account.CustomerID
+}
+
+func pocketName(account *types.Account) string {
+	if account.Pocket == "" {
+		return MainPocket
+	}
+
+	return account.Pocket
+}
+
+func (s *Service) parsePocketMoveToString(move *types.PocketMove) string {
+	parsed := move.ID + ";"
+	parsed += strconv.FormatInt(move.CustomerID, 10) + ";"
+	parsed += strconv.FormatInt(move.FromAccountID, 10) + ";"
+	parsed += strconv.FormatInt(move.ToAccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(move.Amount), 10) + ";"
+	parsed += strconv.FormatInt(move.CreatedAt, 10) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToPocketMoves(data string) []*types.PocketMove {
+	var moves []*types.PocketMove
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 6 {
+			continue
+		}
+
+		customerID, _	:= strconv.ParseInt(item[1], 10, 64)
+		fromID, _		:= strconv.ParseInt(item[2], 10, 64)
+		toID, _			:= strconv.ParseInt(item[3], 10, 64)
+		amount, _		:= strconv.ParseInt(item[4], 10, 64)
+		createdAt, _	:= strconv.ParseInt(item[5], 10, 64)
+
+		move := &types.PocketMove {
+			ID:				item[0],
+			CustomerID:		customerID,
+			FromAccountID:	fromID,
+			ToAccountID:	toID,
+			Amount:			types.Money(amount),
+			CreatedAt:		createdAt,
+		}
+
+		moves = append(moves, move)
+	}
+
+	return moves
+}
+
+func (s *Service) containsPocketMove(item *types.PocketMove, items []*types.PocketMove) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,272 @@
+package wallet
+
+import (
+	"strconv"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_OpenPocket_fail(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		pocket	string
+		want	error
+	} {
+		{ name: "empty",	pocket: " ",		want: ErrInvalidPocketName },
+		{ name: "main",		pocket: "Main",		want: ErrInvalidPocketName },
+		{ name: "reserved",	pocket: "a;b",		want: ErrInvalidPocketName },
+		{ name: "taken",	pocket: "Savings",	want: ErrPocketNameTaken },
+	}
+
+	for _, test := range tests {
+		_, err := s.OpenPocket(main.ID, test.pocket)
+		if err != test.want {
+			t.Errorf("%s: OpenPocket(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+
+	travel, err := s.OpenPocket(savings.ID, "travel")
+	if err != nil || travel.CustomerID != main.ID {
+		t.Errorf("OpenPocket(): pocket opened through other pocket must belong to the customer, pocket = %v, error = %v", travel, err)
+		return
+	}
+
+	pockets, err := s.Pockets(travel.ID)
+	if err != nil || len(pockets) != 3 || pockets[0] != main {
+		t.Errorf("Pockets(): wrong pockets = %v, error = %v", pockets, err)
+		return
+	}
+
+	_, err = s.ChangePhone(savings.ID, "+992937452946")
+	if err != ErrMainPocketRequired {
+		t.Errorf("ChangePhone(): must return ErrMainPocketRequired, but error = %v", err)
+		return
+	}
+}
+
+func TestService_MoveBetweenPockets_success(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimit(types.SpendingLimit{ AccountID: main.ID, PerPayment: 100 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	move, err := s.MoveBetweenPockets(main.ID, savings.ID, 700)
+	if err != nil {
+		t.Errorf("MoveBetweenPockets(): error = %v", err)
+		return
+	}
+
+	if main.Balance != 300 || savings.Balance != 700 || move.CustomerID != main.ID {
+		t.Errorf("MoveBetweenPockets(): wrong balances = %v, %v", main.Balance, savings.Balance)
+		return
+	}
+
+	_, err = s.MoveBetweenPockets(main.ID, savings.ID, 301)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("MoveBetweenPockets(): must return ErrNotEnoughBalance, but error = %v", err)
+		return
+	}
+
+	other, err := s.RegisterAccount("+992937452946")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.MoveBetweenPockets(savings.ID, other.ID, 100)
+	if err != ErrNotSameCustomer {
+		t.Errorf("MoveBetweenPockets(): must return ErrNotSameCustomer, but error = %v", err)
+		return
+	}
+
+	payment, err := s.PayFromPocket(main.ID, "savings", 250, "travel")
+	if err != nil {
+		t.Errorf("PayFromPocket(): error = %v", err)
+		return
+	}
+
+	history, err := s.ExportAccountHistory(savings.ID)
+	if err != nil || len(history) != 2 || (history[0] != payment && history[1] != payment) {
+		t.Errorf("ExportAccountHistory(): wrong pocket history = %v, error = %v", history, err)
+		return
+	}
+
+	balance, err := s.FindCustomerBalance(savings.ID)
+	if err != nil {
+		t.Errorf("FindCustomerBalance(): error = %v", err)
+		return
+	}
+
+	if balance.CustomerID != main.ID || balance.Phone != main.Phone || balance.Balance != 750 || len(balance.Pockets) != 2 || balance.Pockets[1].Pocket != "savings" {
+		t.Errorf("FindCustomerBalance(): wrong balance = %+v", balance)
+		return
+	}
+
+	_, err = s.PayFromPocket(main.ID, "travel", 10, "travel")
+	if err != ErrPocketNotFound {
+		t.Errorf("PayFromPocket(): must return ErrPocketNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_ExportAccountHistory_pocketMoves(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	move, err := s.MoveBetweenPockets(main.ID, savings.ID, 400)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	history, err := s.ExportAccountHistory(savings.ID)
+	if err != nil || len(history) != 1 {
+		t.Errorf("ExportAccountHistory(): pocket funded by moves must have history = %v, error = %v", history, err)
+		return
+	}
+
+	got := history[0]
+	if got.ID != move.ID || got.Amount != 400 || got.Category != PocketMoveCategory || got.Params["from"] != strconv.FormatInt(main.ID, 10) {
+		t.Errorf("ExportAccountHistory(): wrong move = %+v", got)
+		return
+	}
+
+	history, err = s.ExportAccountHistory(main.ID)
+	if err != nil || len(history) != 1 || history[0].Params["to"] != strconv.FormatInt(savings.ID, 10) {
+		t.Errorf("ExportAccountHistory(): wrong main pocket history = %v, error = %v", history, err)
+		return
+	}
+}
+
+func TestService_Close_openPockets(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.MoveBetweenPockets(main.ID, savings.ID, 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Close(main.ID, "customer request")
+	if err != ErrAccountNotEmpty {
+		t.Errorf("Close(): must return ErrAccountNotEmpty, but error = %v", err)
+		return
+	}
+
+	_, err = s.CloseWithPayout(savings.ID, "customer request")
+	if err != nil {
+		t.Errorf("CloseWithPayout(): error = %v", err)
+		return
+	}
+
+	err = s.Close(main.ID, "customer request")
+	if err != nil {
+		t.Errorf("Close(): error = %v", err)
+		return
+	}
+}
+
+func TestService_Export_pockets(t *testing.T) {
+	s := newTestService()
+
+	main, err := s.addAccountWithBalance("+992937452945", 1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	savings, err := s.OpenPocket(main.ID, "savings")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.MoveBetweenPockets(main.ID, savings.ID, 400)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	restored, err := imported.FindPocket(main.ID, "savings")
+	if err != nil || *restored != *savings {
+		t.Errorf("importFrom(): got %+v, want %+v, error = %v", restored, savings, err)
+		return
+	}
+
+	moves := imported.FindPocketMoves(savings.ID)
+	if len(moves) != 1 || *moves[0] != *s.FindPocketMoves(savings.ID)[0] {
+		t.Errorf("importFrom(): wrong moves = %v", moves)
+		return
+	}
+
+	if imported.MigratePhones(true).Invalid != nil {
+		t.Errorf("MigratePhones(): pockets must be skipped")
+		return
+	}
+}
@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrRefundExceedsPayment - refunds would be more than the payment
+var ErrRefundExceedsPayment = errors.New("Refund amount is more than the payment")
+
+// ErrPaymentNotRefundable - payment is rejected or did not charge the account
+var ErrPaymentNotRefundable = errors.New("Payment can't be refunded")
+
+// PaymentRefunds - refund history of the payment
+type PaymentRefunds struct {
+	Payment		*types.Payment
+	Refunded	types.Money
+	Net			types.Money		// amount of the payment left after refunds
+	Refunds		[]*types.Refund
+}
+
+// Refund returns part of the payment to the account, a payment can be refunded
+// several times until the whole amount is returned
+func (s *Service) Refund(paymentID string, amount types.Money) (*types.Refund, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !charged(payment.Status) || exchanged(payment) || transferred(payment) || isFee(payment) {
+		return nil, ErrPaymentNotRefundable
+	}
+
+	if s.disputed(paymentID) {
+		return nil, ErrPaymentDisputed
+	}
+
+	if amount > payment.Amount - s.refunded(paymentID) {
+		return nil, ErrRefundExceedsPayment
+	}
+
+	account, err := s.findOpenAccount(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := account.Balance.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &types.Refund {
+		ID:			s.newID(),
+		PaymentID:	paymentID,
+		AccountID:	account.ID,
+		Amount:		amount,
+		CreatedAt:	s.now().Unix(),
+	}
+
+	account.Balance = balance
+	s.refunds = append(s.refunds, refund)
+	s.logger().Log(LevelInfo, "payment refunded", Op("refund"), AccountID(account.ID), PaymentID(paymentID), Field{ Key: "amount", Value: amount })
+
+	return refund, nil
+}
+
+// FindRefunds returns net amount and refunds of the payment
+func (s *Service) FindRefunds(paymentID string) (*PaymentRefunds, error) {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &PaymentRefunds{ Payment: payment }
+	for _, refund := range s.refunds {
+		if refund.PaymentID == paymentID {
+			history.Refunds = append(history.Refunds, refund)
+			history.Refunded += refund.Amount
+		}
+	}
+	history.Net = payment.Amount - history.Refunded
+
+	return history, nil
+}
+
+// refunded sums refunds of the payment
+func (s *Service) refunded(paymentID string) types.Money {
+	sum := types.Money(0)
+	for _, refund := range s.refunds {
+		if refund.PaymentID == paymentID {
+			sum += refund.Amount
+		}
+	}
+
+	return sum
+}
+
+func (s *Service) parseRefundToString(refund *types.Refund) string {
+	parsed := refund.ID + ";"
+	parsed += refund.PaymentID + ";"
+	parsed += strconv.FormatInt(refund.AccountID, 10) + ";"
+	parsed += strconv.FormatInt(int64(refund.Amount), 10) + ";"
+	parsed += strconv.FormatInt(refund.CreatedAt, 10) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToRefunds(data string) []*types.Refund {
+	var refunds []*types.Refund
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 5 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[2], 10, 64)
+		amount, _		:= strconv.ParseInt(item[3], 10, 64)
+		createdAt, _	:= strconv.ParseInt(item[4], 10, 64)
+
+		refund := &types.Refund {
+			ID:			item[0],
+			PaymentID:	item[1],
+			AccountID:	accountID,
+			Amount:		types.Money(amount),
+			CreatedAt:	createdAt,
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds
+}
+
+func (s *Service) containsRefund(item *types.Refund, items []*types.Refund) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
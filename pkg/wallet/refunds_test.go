@@ -0,0 +1,206 @@
+package wallet
+
+import (
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_Refund_partial(t *testing.T) {
+	s := newTestService()
+	account, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	balance := account.Balance
+
+	first, err := s.Refund(payment.ID, 300)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	second, err := s.Refund(payment.ID, 200)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	if first.PaymentID != payment.ID || first.ID == second.ID {
+		t.Errorf("Refund(): wrong refunds = %+v, %+v", first, second)
+		return
+	}
+
+	if account.Balance != balance + 500 {
+		t.Errorf("Refund(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	history, err := s.FindRefunds(payment.ID)
+	if err != nil {
+		t.Errorf("FindRefunds(): error = %v", err)
+		return
+	}
+
+	if history.Refunded != 500 || history.Net != payment.Amount - 500 || len(history.Refunds) != 2 {
+		t.Errorf("FindRefunds(): wrong history = %+v", history)
+		return
+	}
+}
+
+func TestService_Refund_fail(t *testing.T) {
+	s := newTestService()
+	_, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+
+	_, err = s.Refund(payment.ID, 0)
+	if err != ErrAmountMustBePositive {
+		t.Errorf("Refund(): must return ErrAmountMustBePositive, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund("unknown", 100)
+	if err != ErrPaymentNotFound {
+		t.Errorf("Refund(): must return ErrPaymentNotFound, but error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, payment.Amount)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 1)
+	if err != ErrRefundExceedsPayment {
+		t.Errorf("Refund(): must return ErrRefundExceedsPayment, but error = %v", err)
+		return
+	}
+
+	rejected, err := s.Pay(payment.AccountID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	err = s.Reject(rejected.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(rejected.ID, 1)
+	if err != ErrPaymentNotRefundable {
+		t.Errorf("Refund(): must return ErrPaymentNotRefundable, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Reject_afterRefund(t *testing.T) {
+	s := newTestService()
+	account, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	before := account.Balance + payment.Amount
+
+	_, err = s.Refund(payment.ID, 400)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	if account.Balance != before {
+		t.Errorf("Reject(): must return only net amount, balance = %v, want %v", account.Balance, before)
+		return
+	}
+}
+
+func TestService_Export_refunds(t *testing.T) {
+	s := newTestService()
+	_, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	refund, err := s.Refund(payments[0].ID, 100)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	history, err := imported.FindRefunds(payments[0].ID)
+	if err != nil {
+		t.Errorf("FindRefunds(): error = %v", err)
+		return
+	}
+
+	if len(history.Refunds) != 1 || *history.Refunds[0] != *refund {
+		t.Errorf("importFrom(): wrong refunds = %+v", history.Refunds)
+		return
+	}
+
+	if history.Net != payments[0].Amount - 100 {
+		t.Errorf("importFrom(): wrong net = %v", history.Net)
+		return
+	}
+}
+
+func TestService_Pay_refundsFreeLimit(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addLimitedAccount(types.SpendingLimit{ Daily: 100 })
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 30)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 30, "auto")
+	if err != nil {
+		t.Errorf("Pay(): refunded part must not count, error = %v", err)
+		return
+	}
+}
@@ -0,0 +1,400 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/cron"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrScheduleNotFound - schedule does not exist
+var ErrScheduleNotFound = errors.New("Schedule not found")
+
+// ErrInvalidSchedule - schedule spec can't be parsed
+var ErrInvalidSchedule = errors.New("Invalid schedule")
+
+// CatchUp - what to do with runs missed while the scheduler was not running
+type CatchUp int
+
+// Catch up policies
+const (
+	CatchUpLatest	CatchUp = iota	// run only the latest missed run
+	CatchUpAll						// run every missed run
+	CatchUpNone						// skip missed runs
+)
+
+// SchedulePolicy - retries and catch up of scheduled payments
+type SchedulePolicy struct {
+	CatchUp		CatchUp
+	Grace		time.Duration	// runs late by no more than Grace are not missed
+	MaxRetries	int				// retries of a run failed with ErrNotEnoughBalance
+	Backoff		time.Duration	// delay before the first retry, doubled for each next one
+}
+
+// Bounds of schedule policies
+const (
+	MaxScheduleRetries	= 10				// MaxRetries can't be greater
+	MaxRetryDelay		= 7 * 24 * time.Hour	// doubled backoff stops growing here
+	MaxRunsPerTick		= 100				// runs of one schedule per RunDueSchedules, the rest wait for next ticks
+)
+
+// DefaultSchedulePolicy - policy used when none is configured
+var DefaultSchedulePolicy = SchedulePolicy {
+	CatchUp:	CatchUpLatest,
+	Grace:		5 * time.Minute,
+	MaxRetries:	3,
+	Backoff:	time.Hour,
+}
+
+// SchedulePayment pays the favorite by the spec, see cron.Parse for supported specs,
+// times are in UTC
+func (s *Service) SchedulePayment(favoriteID string, spec string) (*types.Schedule, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.findOpenAccount(favorite.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := cron.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	next := parsed.Next(s.now().UTC())
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, spec)
+	}
+
+	schedule := &types.Schedule {
+		ID:			s.newID(),
+		FavoriteID:	favoriteID,
+		Spec:		spec,
+		NextRun:	next.Unix(),
+	}
+
+	s.schedules = append(s.schedules, schedule)
+	s.logger().Log(LevelInfo, "payment scheduled", Op("schedule"), FavoriteID(favoriteID), Field{ Key: "spec", Value: spec })
+
+	return schedule, nil
+}
+
+// CancelSchedule removes the schedule, its runs are kept
+func (s *Service) CancelSchedule(scheduleID string) error {
+	schedule, err := s.FindScheduleByID(scheduleID)
+	if err != nil {
+		return err
+	}
+
+	favorite, err := s.FindFavoriteByID(schedule.FavoriteID)
+	if err == nil {
+		_, err = s.findOpenAccount(favorite.AccountID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, value := range s.schedules {
+		if value == schedule {
+			s.schedules = append(s.schedules[:i], s.schedules[i + 1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// FindScheduleByID returns schedule by id
+func (s *Service) FindScheduleByID(scheduleID string) (*types.Schedule, error) {
+	for _, schedule := range s.schedules {
+		if schedule.ID == scheduleID {
+			return schedule, nil
+		}
+	}
+
+	return nil, ErrScheduleNotFound
+}
+
+// GetSchedules returns schedules
+func (s *Service) GetSchedules() []*types.Schedule {
+	return s.schedules
+}
+
+// FindScheduleRuns returns runs of the schedule
+func (s *Service) FindScheduleRuns(scheduleID string) []*types.ScheduleRun {
+	var runs []*types.ScheduleRun
+	for _, run := range s.scheduleRuns {
+		if run.ScheduleID == scheduleID {
+			runs = append(runs, run)
+		}
+	}
+
+	return runs
+}
+
+// RunDueSchedules executes schedules which are due by the service clock and returns their runs
+func (s *Service) RunDueSchedules() []*types.ScheduleRun {
+	var runs []*types.ScheduleRun
+
+	now := s.now().UTC()
+	for _, schedule := range s.schedules {
+		runs = append(runs, s.runSchedule(schedule, now)...)
+	}
+
+	return runs
+}
+
+// Scheduler runs due schedules of the service periodically
+type Scheduler struct {
+	svc			*Service
+	locker		sync.Locker
+	interval	time.Duration
+}
+
+// NewScheduler creates scheduler, locker guards the service if it is shared, e.g. with a server
+func NewScheduler(svc *Service, locker sync.Locker, interval time.Duration) *Scheduler {
+	return &Scheduler{ svc: svc, locker: locker, interval: interval }
+}
+
+// Run checks schedules every interval until the context is done
+func (sc *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+
+	for {
+		sc.tick()
+
+		select {
+		case <- ctx.Done():
+			return ctx.Err()
+		case <- ticker.C:
+		}
+	}
+}
+
+func (sc *Scheduler) tick() {
+	if sc.locker != nil {
+		sc.locker.Lock()
+		defer sc.locker.Unlock()
+	}
+
+	sc.svc.RunDueSchedules()
+}
+
+func (s *Service) schedulePolicy() SchedulePolicy {
+	if s.policy == nil {
+		return DefaultSchedulePolicy
+	}
+
+	return *s.policy
+}
+
+// runSchedule executes due runs of the schedule, missed runs are handled by the catch up policy
+func (s *Service) runSchedule(schedule *types.Schedule, now time.Time) []*types.ScheduleRun {
+	var runs []*types.ScheduleRun
+
+	spec, err := cron.Parse(schedule.Spec)
+	if err != nil {
+		s.logger().Log(LevelError, "can't parse schedule", Op("schedule"), Field{ Key: "schedule", Value: schedule.ID }, Err(err))
+		return nil
+	}
+
+	policy := s.schedulePolicy()
+	for schedule.NextRun != 0 && schedule.NextRun <= now.Unix() && schedule.RetryAt <= now.Unix() {
+		if len(runs) >= MaxRunsPerTick {
+			break
+		}
+
+		due := time.Unix(schedule.NextRun, 0).UTC()
+		next := spec.Next(due)
+
+		missed := now.Sub(due) > policy.Grace
+		latest := next.IsZero() || next.After(now)
+		catchUp := policy.CatchUp == CatchUpAll || (policy.CatchUp == CatchUpLatest && latest)
+
+		if missed && !catchUp && schedule.RetryAt == 0 {
+			runs = append(runs, s.recordRun(schedule, due, now, types.ScheduleRunSkipped, nil, nil))
+			advanceSchedule(schedule, next)
+			continue
+		}
+
+		payment, err := s.PayFromFavorite(schedule.FavoriteID)
+		if errors.Is(err, ErrNotEnoughBalance) && schedule.Attempts < policy.MaxRetries {
+			schedule.Attempts++
+			schedule.RetryAt = now.Add(retryDelay(policy.Backoff, schedule.Attempts)).Unix()
+			runs = append(runs, s.recordRun(schedule, due, now, types.ScheduleRunRetry, nil, err))
+			break
+		}
+
+		status := types.ScheduleRunOk
+		if err != nil {
+			status = types.ScheduleRunFailed
+		}
+
+		runs = append(runs, s.recordRun(schedule, due, now, status, payment, err))
+		advanceSchedule(schedule, next)
+	}
+
+	return runs
+}
+
+func (s *Service) recordRun(schedule *types.Schedule, due time.Time, now time.Time, status types.ScheduleRunStatus, payment *types.Payment, err error) *types.ScheduleRun {
+	run := &types.ScheduleRun {
+		ID:			s.newID(),
+		ScheduleID:	schedule.ID,
+		DueAt:		due.Unix(),
+		At:			now.Unix(),
+		Status:		status,
+	}
+
+	if payment != nil {
+		run.PaymentID = payment.ID
+	}
+
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	s.scheduleRuns = append(s.scheduleRuns, run)
+	s.logger().Log(LevelInfo, "schedule run", Op("schedule"), Field{ Key: "schedule", Value: schedule.ID }, Field{ Key: "status", Value: status })
+
+	return run
+}
+
+// retryDelay doubles backoff for each attempt after the first one, up to MaxRetryDelay
+func retryDelay(backoff time.Duration, attempts int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > MaxRetryDelay {
+		delay = MaxRetryDelay
+	}
+
+	return delay
+}
+
+// advanceSchedule moves the schedule to the next run, zero next time stops the schedule
+func advanceSchedule(schedule *types.Schedule, next time.Time) {
+	schedule.NextRun = 0
+	if !next.IsZero() {
+		schedule.NextRun = next.Unix()
+	}
+
+	schedule.Attempts = 0
+	schedule.RetryAt = 0
+}
+
+func (s *Service) parseScheduleToString(schedule *types.Schedule) string {
+	parsed := schedule.ID + ";"
+	parsed += schedule.FavoriteID + ";"
+	parsed += schedule.Spec + ";"
+	parsed += strconv.FormatInt(schedule.NextRun, 10) + ";"
+	parsed += strconv.Itoa(schedule.Attempts) + ";"
+	parsed += strconv.FormatInt(schedule.RetryAt, 10) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToSchedules(data string) []*types.Schedule {
+	var schedules []*types.Schedule
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 6 {
+			continue
+		}
+
+		nextRun, _	:= strconv.ParseInt(item[3], 10, 64)
+		attempts, _	:= strconv.Atoi(item[4])
+		retryAt, _	:= strconv.ParseInt(item[5], 10, 64)
+
+		schedule := &types.Schedule {
+			ID:			item[0],
+			FavoriteID:	item[1],
+			Spec:		item[2],
+			NextRun:	nextRun,
+			Attempts:	attempts,
+			RetryAt:	retryAt,
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules
+}
+
+func (s *Service) containsSchedule(item *types.Schedule, items []*types.Schedule) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Service) parseScheduleRunToString(run *types.ScheduleRun) string {
+	parsed := run.ID + ";"
+	parsed += run.ScheduleID + ";"
+	parsed += strconv.FormatInt(run.DueAt, 10) + ";"
+	parsed += strconv.FormatInt(run.At, 10) + ";"
+	parsed += string(run.Status) + ";"
+	parsed += run.PaymentID + ";"
+	parsed += url.QueryEscape(run.Error) + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToScheduleRuns(data string) []*types.ScheduleRun {
+	var runs []*types.ScheduleRun
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 7 {
+			continue
+		}
+
+		dueAt, _	:= strconv.ParseInt(item[2], 10, 64)
+		at, _		:= strconv.ParseInt(item[3], 10, 64)
+		message, _	:= url.QueryUnescape(item[6])
+
+		run := &types.ScheduleRun {
+			ID:			item[0],
+			ScheduleID:	item[1],
+			DueAt:		dueAt,
+			At:			at,
+			Status:		types.ScheduleRunStatus(item[4]),
+			PaymentID:	item[5],
+			Error:		message,
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+func (s *Service) containsScheduleRun(item *types.ScheduleRun, items []*types.ScheduleRun) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
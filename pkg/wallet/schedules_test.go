@@ -0,0 +1,416 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addFavoriteWithBalance(balance types.Money) (*types.Account, *types.Favorite, error) {
+	account, err := s.addAccountWithBalance("+992000000001", 1_000)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payment, err := s.Pay(account.ID, 100, "internet")
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't make payment, error = %v", err)
+	}
+
+	favorite, err := s.FavoritePayment(payment.ID, "internet")
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't make favorite, error = %v", err)
+	}
+
+	account.Balance = balance
+	return account, favorite, nil
+}
+
+func runStatuses(runs []*types.ScheduleRun) []types.ScheduleRunStatus {
+	var statuses []types.ScheduleRunStatus
+	for _, run := range runs {
+		statuses = append(statuses, run.Status)
+	}
+
+	return statuses
+}
+
+func sameStatuses(got []types.ScheduleRunStatus, want ...types.ScheduleRunStatus) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestService_SchedulePayment_fail(t *testing.T) {
+	s := newTestService()
+
+	_, favorite, err := s.addFavoriteWithBalance(1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SchedulePayment("unknown", "daily 09:00")
+	if err != ErrFavoriteNotFound {
+		t.Errorf("SchedulePayment(): must return ErrFavoriteNotFound, but error = %v", err)
+		return
+	}
+
+	_, err = s.SchedulePayment(favorite.ID, "hourly")
+	if !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("SchedulePayment(): must return ErrInvalidSchedule, but error = %v", err)
+		return
+	}
+
+	err = s.CancelSchedule("unknown")
+	if err != ErrScheduleNotFound {
+		t.Errorf("CancelSchedule(): must return ErrScheduleNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_RunDueSchedules_success(t *testing.T) {
+	s := newTestService()
+	// Monday
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, favorite, err := s.addFavoriteWithBalance(1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorite.ID, "daily 09:00")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+
+	if len(s.RunDueSchedules()) != 0 {
+		t.Error("RunDueSchedules(): schedule must not run before due time")
+		return
+	}
+
+	clock.Set(time.Date(2021, 3, 1, 9, 1, 0, 0, time.UTC))
+	runs := s.RunDueSchedules()
+	if !sameStatuses(runStatuses(runs), types.ScheduleRunOk) {
+		t.Errorf("RunDueSchedules(): wrong runs = %v", runStatuses(runs))
+		return
+	}
+
+	if account.Balance != 900 || runs[0].PaymentID == "" {
+		t.Errorf("RunDueSchedules(): payment is not made, balance = %v, run = %+v", account.Balance, runs[0])
+		return
+	}
+
+	if schedule.NextRun != time.Date(2021, 3, 2, 9, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("RunDueSchedules(): wrong next run = %v", time.Unix(schedule.NextRun, 0).UTC())
+		return
+	}
+
+	err = s.CancelSchedule(schedule.ID)
+	if err != nil {
+		t.Errorf("CancelSchedule(): error = %v", err)
+		return
+	}
+
+	clock.Advance(24 * time.Hour)
+	if len(s.RunDueSchedules()) != 0 || len(s.FindScheduleRuns(schedule.ID)) != 1 {
+		t.Error("RunDueSchedules(): cancelled schedule must not run, but runs must be kept")
+		return
+	}
+}
+
+func TestService_RunDueSchedules_retry(t *testing.T) {
+	s := newTestService()
+	// Monday
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+	s.policy = &SchedulePolicy {
+		CatchUp:	CatchUpLatest,
+		Grace:		time.Minute,
+		MaxRetries:	2,
+		Backoff:	time.Hour,
+	}
+
+	account, favorite, err := s.addFavoriteWithBalance(50)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorite.ID, "daily 09:00")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+
+	clock.Set(time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC))
+	runs := s.RunDueSchedules()
+	if !sameStatuses(runStatuses(runs), types.ScheduleRunRetry) || runs[0].Error != ErrNotEnoughBalance.Error() {
+		t.Errorf("RunDueSchedules(): wrong runs = %+v", runs)
+		return
+	}
+
+	// first retry after an hour, second after two more hours
+	clock.Advance(59 * time.Minute)
+	if len(s.RunDueSchedules()) != 0 {
+		t.Error("RunDueSchedules(): must wait for backoff")
+		return
+	}
+
+	clock.Advance(time.Minute)
+	runs = s.RunDueSchedules()
+	if !sameStatuses(runStatuses(runs), types.ScheduleRunRetry) || schedule.RetryAt != clock.Now().Add(2 * time.Hour).Unix() {
+		t.Errorf("RunDueSchedules(): wrong retry, runs = %v, schedule = %+v", runStatuses(runs), schedule)
+		return
+	}
+
+	account.Balance = 1_000
+	clock.Advance(2 * time.Hour)
+	runs = s.RunDueSchedules()
+	if !sameStatuses(runStatuses(runs), types.ScheduleRunOk) || account.Balance != 900 {
+		t.Errorf("RunDueSchedules(): retry must pay, runs = %v, balance = %v", runStatuses(runs), account.Balance)
+		return
+	}
+
+	if runs[0].DueAt != time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC).Unix() || schedule.Attempts != 0 || schedule.RetryAt != 0 {
+		t.Errorf("RunDueSchedules(): wrong run = %+v, schedule = %+v", runs[0], schedule)
+		return
+	}
+
+	// retries are exhausted
+	account.Balance = 0
+	clock.Set(time.Date(2021, 3, 2, 9, 0, 0, 0, time.UTC))
+	s.RunDueSchedules()
+	clock.Advance(time.Hour)
+	s.RunDueSchedules()
+	clock.Advance(2 * time.Hour)
+	runs = s.RunDueSchedules()
+	if !sameStatuses(runStatuses(runs), types.ScheduleRunFailed) {
+		t.Errorf("RunDueSchedules(): must fail after retries, runs = %v", runStatuses(runs))
+		return
+	}
+
+	if schedule.NextRun != time.Date(2021, 3, 3, 9, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("RunDueSchedules(): failed run must move schedule, next = %v", time.Unix(schedule.NextRun, 0).UTC())
+		return
+	}
+}
+
+func TestService_RunDueSchedules_catchUp(t *testing.T) {
+	tests := []struct {
+		name	string
+		catchUp	CatchUp
+		want	[]types.ScheduleRunStatus
+	} {
+		{
+			name:		"latest",
+			catchUp:	CatchUpLatest,
+			want:		[]types.ScheduleRunStatus{ types.ScheduleRunSkipped, types.ScheduleRunSkipped, types.ScheduleRunOk },
+		},
+		{
+			name:		"all",
+			catchUp:	CatchUpAll,
+			want:		[]types.ScheduleRunStatus{ types.ScheduleRunOk, types.ScheduleRunOk, types.ScheduleRunOk },
+		},
+		{
+			name:		"none",
+			catchUp:	CatchUpNone,
+			want:		[]types.ScheduleRunStatus{ types.ScheduleRunSkipped, types.ScheduleRunSkipped, types.ScheduleRunSkipped },
+		},
+	}
+
+	for _, test := range tests {
+		policy := DefaultSchedulePolicy
+		policy.CatchUp = test.catchUp
+
+		s := newTestService()
+		// Monday
+		clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+		s.clock = clock
+		s.policy = &policy
+
+		_, favorite, err := s.addFavoriteWithBalance(1_000)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		schedule, err := s.SchedulePayment(favorite.ID, "daily 09:00")
+		if err != nil {
+			t.Errorf("%s: SchedulePayment(): error = %v", test.name, err)
+			continue
+		}
+
+		// down for three days
+		clock.Set(time.Date(2021, 3, 3, 12, 0, 0, 0, time.UTC))
+		runs := s.RunDueSchedules()
+		if !sameStatuses(runStatuses(runs), test.want...) {
+			t.Errorf("%s: RunDueSchedules(): got %v, want %v", test.name, runStatuses(runs), test.want)
+			continue
+		}
+
+		if schedule.NextRun != time.Date(2021, 3, 4, 9, 0, 0, 0, time.UTC).Unix() {
+			t.Errorf("%s: RunDueSchedules(): wrong next run = %v", test.name, time.Unix(schedule.NextRun, 0).UTC())
+		}
+	}
+}
+
+func TestService_RunDueSchedules_catchUpLimit(t *testing.T) {
+	policy := DefaultSchedulePolicy
+	policy.CatchUp = CatchUpAll
+
+	s := newTestService()
+	// Monday
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+	s.policy = &policy
+
+	account, favorite, err := s.addFavoriteWithBalance(1_000_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorite.ID, "daily 09:00")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+
+	// down for 150 days
+	clock.Set(time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC).AddDate(0, 0, 149))
+	runs := s.RunDueSchedules()
+	if len(runs) != MaxRunsPerTick || account.Balance != 1_000_000 - 100 * MaxRunsPerTick {
+		t.Errorf("RunDueSchedules(): must run at most %d runs, got %d, balance = %v", MaxRunsPerTick, len(runs), account.Balance)
+		return
+	}
+
+	runs = s.RunDueSchedules()
+	if len(runs) != 150 - MaxRunsPerTick || schedule.NextRun != time.Date(2021, 7, 29, 9, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("RunDueSchedules(): next tick must run the rest, got %d, next run = %v", len(runs), time.Unix(schedule.NextRun, 0).UTC())
+		return
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		backoff		time.Duration
+		attempts	int
+		want		time.Duration
+	} {
+		{ backoff: time.Hour,				attempts: 1,					want: time.Hour },
+		{ backoff: time.Hour,				attempts: 3,					want: 4 * time.Hour },
+		{ backoff: time.Hour,				attempts: MaxScheduleRetries,	want: MaxRetryDelay },
+		{ backoff: 1<<62 * time.Nanosecond,	attempts: MaxScheduleRetries,	want: MaxRetryDelay },
+	}
+
+	for _, test := range tests {
+		got := retryDelay(test.backoff, test.attempts)
+		if got != test.want {
+			t.Errorf("retryDelay(%v, %d): got %v, want %v", test.backoff, test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestService_Export_schedules(t *testing.T) {
+	s := newTestService()
+	// Monday
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	_, favorite, err := s.addFavoriteWithBalance(1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorite.ID, "0 9 * * 1-5")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+
+	clock.Set(time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC))
+	s.RunDueSchedules()
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	restored, err := imported.FindScheduleByID(schedule.ID)
+	if err != nil {
+		t.Errorf("FindScheduleByID(): error = %v", err)
+		return
+	}
+
+	if *restored != *schedule {
+		t.Errorf("importFrom(): wrong schedule = %+v, want %+v", restored, schedule)
+		return
+	}
+
+	runs := imported.FindScheduleRuns(schedule.ID)
+	if len(runs) != 1 || runs[0].Status != types.ScheduleRunOk {
+		t.Errorf("importFrom(): wrong runs = %+v", runs)
+		return
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := newTestService()
+	// Monday
+	clock := NewManualClock(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC))
+	s.clock = clock
+
+	account, favorite, err := s.addFavoriteWithBalance(1_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SchedulePayment(favorite.ID, "daily 09:00")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+	clock.Set(time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+	defer cancel()
+
+	mu := &sync.Mutex{}
+	err = NewScheduler(s.Service, mu, time.Millisecond).Run(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Run(): must stop with context, error = %v", err)
+		return
+	}
+
+	if account.Balance != 900 {
+		t.Errorf("Run(): due payment must be made once, balance = %v", account.Balance)
+		return
+	}
+}
@@ -2,15 +2,20 @@ package wallet
 
 import (
 	"sync"
+	"time"
 	"math"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"io"
 	"strconv"
-	"log"
 	"os"
-	"github.com/google/uuid"
 	"errors"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/exchange"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/fees"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/money"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/operator"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/phone"
 	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
 )
 
@@ -32,36 +37,102 @@ var ErrPaymentNotFound = errors.New("Payment not found")
 // ErrFavoriteNotFound - favorite does not exist
 var ErrFavoriteNotFound = errors.New("Favorite not found")
 
+// ErrStorageNotConfigured - Save or Load is called on service without storage
+var ErrStorageNotConfigured = errors.New("Storage is not configured")
+
+// ErrLimitExceeded - operation exceeds configured limit
+var ErrLimitExceeded = errors.New("Limit exceeded")
+
+// ErrPaymentNotRejectable - payment did not charge the account, e.g. voided or expired hold
+var ErrPaymentNotRejectable = errors.New("Payment can't be rejected")
+
+// ErrPaymentNotRepeatable - fee lines and released holds can't be repeated
+var ErrPaymentNotRepeatable = errors.New("Payment can't be repeated")
+
+// Names of dump files written by Export
+const (
+	accountsDump	= "accounts.dump"
+	paymentsDump	= "payments.dump"
+	favoritesDump	= "favorites.dump"
+	limitsDump		= "limits.dump"
+	holdsDump		= "holds.dump"
+	refundsDump		= "refunds.dump"
+	disputesDump	= "disputes.dump"
+	schedulesDump	= "schedules.dump"
+	runsDump		= "schedule_runs.dump"
+	templatesDump	= "templates.dump"
+	phonesDump		= "phones.dump"
+	movesDump		= "pocket_moves.dump"
+	membersDump		= "members.dump"
+	exchangesDump	= "exchanges.dump"
+)
+
 // Service - storage for payments and accounts
 type Service struct {
 	nextAccountID	int64
 	accounts 		[]*types.Account
 	payments 		[]*types.Payment
 	favorites		[]*types.Favorite
+	log				Logger
+	ids				IDGenerator
+	clock			Clock
+	storage			Storage
+	currency		types.Currency
+	limits			map[types.Currency]Limits
+	spendingLimits	[]*types.SpendingLimit
+	holds			[]*types.Hold
+	holdExpiry		time.Duration
+	refunds			[]*types.Refund
+	disputes		[]*types.Dispute
+	schedules		[]*types.Schedule
+	scheduleRuns	[]*types.ScheduleRun
+	policy			*SchedulePolicy
+	templates		[]*types.Template
+	phones			*phone.Table
+	operators		*operator.Registry
+	phoneHistory	[]*types.PhoneRecord
+	phoneGrace		time.Duration
+	pocketMoves		[]*types.PocketMove
+	members			[]*types.Member
+	rates			*exchange.Table
+	quoteExpiry		time.Duration
+	exchanges		[]*types.Exchange
+	fees			*fees.Schedule
 }
 
-// Progress used for summing payments
+// Progress used for summing payments, Result is the sum in the default currency,
+// Err is ErrOverflow if sums of the part don't fit into Money
 type Progress struct {
 	Part 	int
 	Result 	types.Money
+	Totals	money.Totals
+	Err		error
 }
 
-// RegisterAccount registering new account
-func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
-	for _, account := range s.accounts {
-		if account.Phone == phone {
-			return nil, ErrPhoneRegistered
-		}
+// RegisterAccount registering new account, phone is saved in E.164 form
+func (s *Service) RegisterAccount(number types.Phone) (*types.Account, error) {
+	normalized, err := s.phoneTable().Normalize(string(number))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.FindAccountByPhone(normalized)
+	if err == nil {
+		return nil, ErrPhoneRegistered
 	}
 
 	s.nextAccountID++
 	account := &types.Account {
 		ID:			s.nextAccountID,
-		Phone:		phone,
+		Phone:		normalized,
 		Balance:	0,
+		Status:		types.AccountStatusActive,
+		Currency:	s.Currency(),
 	}
 
 	s.accounts = append(s.accounts, account)
+	s.logger().Log(LevelInfo, "account registered", Op("register"), AccountID(account.ID))
+
 	return account, nil
 }
 
@@ -71,16 +142,33 @@ func (s *Service) Deposit(accountID int64, amount types.Money) error {
 		return ErrAmountMustBePositive
 	}
 
-	account, err := s.FindAccountByID(accountID)
+	account, err := s.findOpenAccount(accountID)
 	if err != nil {
 		return err
 	}
 
-	account.Balance += amount
+	limits := s.limitsOf(AccountCurrency(account))
+	if limits.MaxDeposit > 0 && amount > limits.MaxDeposit {
+		return &LimitError{ Limit: LimitMaxDeposit, Max: limits.MaxDeposit, Remaining: limits.MaxDeposit }
+	}
+
+	balance, err := account.Balance.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxBalance > 0 && balance > limits.MaxBalance {
+		return exceeds(LimitMaxBalance, "", limits.MaxBalance, account.Balance, amount)
+	}
+
+	account.Balance = balance
+	s.logger().Log(LevelInfo, "deposit", Op("deposit"), AccountID(accountID), Field{ Key: "amount", Value: amount })
+
 	return nil
 }
 
-// Pay is a payment operation
+// Pay is a payment operation, fee of the schedule is debited on top of the amount
+// and recorded as a separate payment
 func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
 	if amount <= 0 {
 		return nil, ErrAmountMustBePositive
@@ -91,12 +179,39 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		return nil, err
 	}
 
-	if account.Balance < amount {
+	err = s.checkAccount(account, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	err = s.checkLimits(accountID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := s.feeFor(AccountCurrency(account), category, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Available() < total {
 		return nil, ErrNotEnoughBalance
 	}
 
-	account.Balance -= amount
-	paymentID := uuid.New().String()
+	balance, err := account.Balance.Sub(total)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Balance = balance
+	paymentID := s.newID()
 
 	payment := &types.Payment {
 		ID:			paymentID,
@@ -104,37 +219,83 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		Amount:		amount,
 		Category:	category,
 		Status:		types.PaymentStatusInProgress,
+		CreatedAt:	s.now().Unix(),
+		Currency:	AccountCurrency(account),
 	}
 
 	s.payments = append(s.payments, payment)
+	if fee > 0 {
+		s.chargeFee(accountID, paymentID, fee, payment.Currency, payment.Status)
+	}
+	s.logger().Log(LevelInfo, "payment created", Op("pay"), AccountID(accountID), PaymentID(paymentID))
+
 	return payment, nil
 }
 
-// Reject cencel payment
+// Reject cencel payment, its fee is returned too. Payments that are rejected or released
+// already and payments with resolved disputes have nothing to return
 func (s *Service) Reject(paymentID string) error {
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return err
 	}
 
-	account, err := s.FindAccountByID(payment.AccountID)
+	if !charged(payment.Status) {
+		return ErrPaymentNotRejectable
+	}
+
+	if s.disputed(paymentID) {
+		return ErrPaymentDisputed
+	}
+
+	if s.everDisputed(paymentID) || exchanged(payment) || transferred(payment) || isFee(payment) {
+		return ErrPaymentNotRejectable
+	}
+
+	account, err := s.findOpenAccount(payment.AccountID)
+	if err != nil {
+		return err
+	}
+
+	returned := payment.Amount - s.refunded(paymentID)
+	fee := s.feeOf(paymentID)
+	if fee != nil && fee.Status != types.PaymentStatusFail {
+		returned, err = returned.Add(fee.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	balance, err := account.Balance.Add(returned)
 	if err != nil {
 		return err
 	}
 
 	payment.Status = types.PaymentStatusFail
-	account.Balance += payment.Amount
+	if fee != nil {
+		fee.Status = types.PaymentStatusFail
+	}
+	account.Balance = balance
+	s.logger().Log(LevelInfo, "payment rejected", Op("reject"), AccountID(account.ID), PaymentID(paymentID))
 
 	return nil
 }
 
-// Repeat payment
+// Repeat payment or transfer, the fee is charged by the current schedule
 func (s *Service) Repeat(paymentID string) (*types.Payment, error) {
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return nil, err
 	}
 
+	if isFee(payment) || released(payment) || exchanged(payment) {
+		return nil, ErrPaymentNotRepeatable
+	}
+
+	if transferred(payment) {
+		return s.repeatTransfer(payment)
+	}
+
 	return s.Pay(payment.AccountID, payment.Amount, payment.Category)
 }
 
@@ -145,16 +306,29 @@ func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorit
 		return nil, err
 	}
 
-	favoriteID := uuid.New().String()
+	_, err = s.findOpenAccount(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFavoriteName(payment.AccountID, "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	favoriteID := s.newID()
 	favorite := &types.Favorite {
 		ID:			favoriteID,
 		AccountID:	payment.AccountID,
 		Name:		name,
 		Amount:		payment.Amount,
 		Category:	payment.Category,
+		Position:	len(s.FindFavoritesByAccountID(payment.AccountID, SortByPosition)),
 	} 
 
 	s.favorites = append(s.favorites, favorite)
+	s.logger().Log(LevelInfo, "favorite created", Op("favorite"), AccountID(favorite.AccountID), FavoriteID(favoriteID))
+
 	return favorite, nil
 }
 
@@ -165,7 +339,15 @@ func (s *Service) PayFromFavorite(favoriteID string) (*types.Payment, error) {
 		return nil, err
 	}
 
-	return s.Pay(favorite.AccountID, favorite.Amount, favorite.Category)
+	payment, err := s.Pay(favorite.AccountID, favorite.Amount, favorite.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	favorite.UsageCount++
+	favorite.LastUsed = payment.CreatedAt
+
+	return payment, nil
 }
 
 // FindAccountByID find account by id
@@ -205,7 +387,7 @@ func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
 func (s *Service) ExportToFile(path string) error {
 	err := s.exportAccountsToFile(path, "|")
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't export accounts", Op("export"), Path(path), Err(err))
 		return err
 	}
 
@@ -216,7 +398,7 @@ func (s *Service) ExportToFile(path string) error {
 func (s *Service) ImportFromFile(path string) error {
 	data, err := s.getDataFromFile(path)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't import accounts", Op("import"), Path(path), Err(err))
 		return err
 	}
 
@@ -230,49 +412,13 @@ func (s *Service) ImportFromFile(path string) error {
 
 // Export all available data (accounts, payments and favorites) to the given dir in files
 func (s *Service) Export(dir string) error {
-	if s.accounts != nil && len(s.accounts) > 0 {
-		fullpath, err := s.getFullPath(dir, "accounts.dump")
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-
-		err = s.exportAccountsToFile(fullpath, "\n") 
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-	}
-
-	if s.payments != nil && len(s.payments) > 0 {
-		fullpath, err := s.getFullPath(dir, "payments.dump")
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-		
-		err = s.exportPaymentsToFile(fullpath)
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-	}
-
-	if s.favorites != nil && len(s.favorites) > 0 {
-		fullpath, err := s.getFullPath(dir, "favorites.dump")
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-		
-		err = s.exportFavoritesToFile(fullpath)
-		if err != nil {
-			log.Println(err)
-			return err
-		}
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		s.logger().Log(LevelError, "can't export", Op("export"), Path(dir), Err(err))
+		return err
 	}
 
-	return nil
+	return s.exportTo(NewDirStorage(path))
 }
 
 // Import all data from the given dir into objects such as accounts, payments and favorites
@@ -286,59 +432,28 @@ func (s *Service) Import(dir string) error {
 		return err
 	}
 
-	accountsPath := path + "/accounts.dump"
-	if s.fileExist(accountsPath) {
-		accounts, err := s.importAccountsFromFile(accountsPath)
-		if err != nil {
-			log.Println(err)
-		}
-
-		for _, dumpAccount := range accounts {
-			if !s.containsAccount(dumpAccount, s.accounts) {
-				s.accounts = append(s.accounts, dumpAccount)
-				s.nextAccountID++
-			}
-		}
-
-		log.Println("size of accounts = ", len(accounts))
-	}
-
-	paymentsPath := path + "/payments.dump"
-	if s.fileExist(paymentsPath) {
-		payments, err := s.importPaymentsFromFile(paymentsPath)
-		if err != nil {
-			log.Println(err)
-		}
-
-		for _, dumpPayment := range payments {
-			if !s.containsPayment(dumpPayment, s.payments) {
-				s.payments = append(s.payments, dumpPayment)
-			}
-		}
+	return s.importFrom(NewDirStorage(path))
+}
 
-		log.Println("size of payments = ", len(payments))
+// Save exports all data to the configured storage
+func (s *Service) Save() error {
+	if s.storage == nil {
+		return ErrStorageNotConfigured
 	}
 
-	favoritesPath := path + "/favorites.dump"
-	if s.fileExist(favoritesPath) {
-		favorites, err := s.importFavoritesFromFile(favoritesPath)
-		if err != nil {
-			log.Println(err)
-		}
-
-		for _, dumpFavorite := range favorites {
-			if !s.containsFavorite(dumpFavorite, s.favorites) {
-				s.favorites = append(s.favorites, dumpFavorite)
-			}
-		}
+	return s.exportTo(s.storage)
+}
 
-		log.Println("size of favorites = ", len(favorites))
+// Load imports all data from the configured storage
+func (s *Service) Load() error {
+	if s.storage == nil {
+		return ErrStorageNotConfigured
 	}
 
-	return nil
+	return s.importFrom(s.storage)
 }
 
-// ExportAccountHistory get payments by accountid
+// ExportAccountHistory get payments by accountid, moves between pockets are included
 func (s *Service) ExportAccountHistory(accountID int64) ([]*types.Payment, error) {
 	var payments []*types.Payment
 
@@ -347,6 +462,7 @@ func (s *Service) ExportAccountHistory(accountID int64) ([]*types.Payment, error
 			payments = append(payments, payment)
 		}
 	}
+	payments = s.withPocketMoves(accountID, payments)
 
 	if payments == nil || len(payments) == 0 {
 		return nil, ErrAccountNotFound
@@ -364,19 +480,19 @@ func (s *Service) HistoryToFiles(payments []*types.Payment, dir string, records
 	if len(payments) <= records {
 		fullpath, err := s.getFullPath(dir, "payments.dump")
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			return err
 		}
 
 		file, err := os.Create(fullpath)
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			return err
 		}
 
 		defer func() {
 			if err := file.Close(); err != nil {
-				log.Println(err)
+				s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			}
 		}()
 
@@ -384,7 +500,7 @@ func (s *Service) HistoryToFiles(payments []*types.Payment, dir string, records
 			parsed := s.parsePaymentToString(payment)
 			_, err := file.Write([]byte(parsed))
 			if err != nil {
-				log.Println(err)
+				s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 				return err
 			}
 		}
@@ -395,19 +511,19 @@ func (s *Service) HistoryToFiles(payments []*types.Payment, dir string, records
 		filename := "payments" + strconv.Itoa(count + 1) + ".dump"
 		fullpath, err := s.getFullPath(dir, filename)
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			return err
 		}
 
 		file, err := os.Create(fullpath)
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			return err
 		}
 
 		defer func() {
 			if err := file.Close(); err != nil {
-				log.Println(err)
+				s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 			}
 		}()
 
@@ -418,13 +534,13 @@ func (s *Service) HistoryToFiles(payments []*types.Payment, dir string, records
 				filename = "payments" + strconv.Itoa(count) + ".dump"
 				fullpath, err = s.getFullPath(dir, filename)
 				if err != nil {
-					log.Println(err)
+					s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 					return err
 				}
 
 				file, err = os.Create(fullpath)
 				if err != nil {
-					log.Println(err)
+					s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 					return err
 				}
 			}
@@ -432,7 +548,7 @@ func (s *Service) HistoryToFiles(payments []*types.Payment, dir string, records
 			parsed := s.parsePaymentToString(payment)
 			_, err = file.Write([]byte(parsed))
 			if err != nil {
-				log.Println(err)
+				s.logger().Log(LevelError, "can't export history", Op("history"), Path(dir), Err(err))
 				return err
 			}
 		}
@@ -446,13 +562,48 @@ func (s *Service) GetPayments() []*types.Payment {
 	return s.payments
 }
 
-// SumPayments returns sum of all payment
+// GetAccounts returns accounts
+func (s *Service) GetAccounts() []*types.Account {
+	return s.accounts
+}
+
+// GetFavorites returns favorites
+func (s *Service) GetFavorites() []*types.Favorite {
+	return s.favorites
+}
+
+// SumPayments returns sum of payments in the default currency of the service.
+// The sum that doesn't fit into Money is logged as error and zero is returned,
+// callers that must tell it from a real zero use SumPaymentsChecked
 func (s *Service) SumPayments(goroutines int) types.Money {
+	sum, err := s.SumPaymentsChecked(goroutines)
+	if err != nil {
+		s.logger().Log(LevelError, "can't sum payments", Op("sum"), Count(len(s.payments)), Err(err))
+		return 0
+	}
+
+	return sum
+}
+
+// SumPaymentsChecked returns sum of payments in the default currency of the service or ErrOverflow,
+// payments in other currencies are summed by SumPaymentsByCurrency
+func (s *Service) SumPaymentsChecked(goroutines int) (types.Money, error) {
+	totals, err := s.SumPaymentsByCurrency(goroutines)
+	if err != nil {
+		return 0, err
+	}
+
+	return totals[s.Currency()], nil
+}
+
+// SumPaymentsByCurrency returns sums of all payments per currency,
+// ErrOverflow if any of them doesn't fit into Money
+func (s *Service) SumPaymentsByCurrency(goroutines int) (money.Totals, error) {
 	if goroutines <= 1 || len(s.payments) == 1 {
-		return s.sumOf(s.payments)
+		return sumsOf(s.payments).Totals()
 	}
 
-	sum := types.Money(0)
+	sums := money.Sums{}
 	proportion := int(math.Ceil(float64(len(s.payments)) / float64(goroutines)))
 	
 	position := 0
@@ -467,11 +618,11 @@ func (s *Service) SumPayments(goroutines int) types.Money {
 	mu := sync.Mutex{}
 	for i := 0; i < position; i++ {
 		wg.Add(1)
-		go s.concurrentSum(&sum, data[i], &wg, &mu)
+		go s.concurrentSum(sums, data[i], &wg, &mu)
 	}
 	wg.Wait()
 	
-	return sum
+	return sums.Totals()
 }
 
 // FilterPayments filters payments by accountID
@@ -611,14 +762,13 @@ func (s *Service) SumPaymentsWithProgress() <-chan Progress {
 		go func(val int) {
 			defer wg.Done()
 
-			sum := types.Money(0)
-			for _, payment := range data[val] {
-				sum += payment.Amount
-			}
+			totals, err := sumsOf(data[val]).Totals()
 
 			progress := Progress {
 				Part:	val,
-				Result: sum,
+				Result: totals[s.Currency()],
+				Totals:	totals,
+				Err:	err,
 			}
 			
 			ch <- progress
@@ -633,25 +783,48 @@ func (s *Service) SumPaymentsWithProgress() <-chan Progress {
 	return ch
 }
 
-func (s *Service) concurrentSum(amount *types.Money, payments []*types.Payment, wg *sync.WaitGroup, mu *sync.Mutex) {
-	sum := types.Money(0)
-	mu.Lock()
-	for _, payment := range payments {
-		sum += payment.Amount
+// logger returns configured logger or the no-op one for zero value service
+func (s *Service) logger() Logger {
+	if s.log == nil {
+		return NopLogger{}
 	}
 
-	*amount += sum
+	return s.log
+}
+
+// newID returns id from the configured generator, random UUID by default
+func (s *Service) newID() string {
+	if s.ids == nil {
+		return UUIDv4Generator{}.NewID()
+	}
+
+	return s.ids.NewID()
+}
+
+// now returns time of the configured clock, system time by default
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return SystemClock{}.Now()
+	}
+
+	return s.clock.Now()
+}
+
+func (s *Service) concurrentSum(sums money.Sums, payments []*types.Payment, wg *sync.WaitGroup, mu *sync.Mutex) {
+	sum := sumsOf(payments)
+	mu.Lock()
+	sums.Merge(sum)
 	mu.Unlock()
 	wg.Done()
 }
 
 func (s *Service) sumOf(payments []*types.Payment) types.Money {
-	sum := types.Money(0)
-
-	for _, payment := range payments {
-		sum += payment.Amount
+	totals, err := sumsOf(payments).Totals()
+	if err != nil {
+		return 0
 	}
-	return sum
+
+	return totals[s.Currency()]
 }
 
 func (s *Service) min(a int, b int) int {
@@ -673,7 +846,7 @@ func (s *Service) fileExist(path string) bool {
 func (s *Service) getFullPath(dir string, filename string) (string, error) {
 	path, err := filepath.Abs(dir)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't resolve path", Op("path"), Path(dir), Err(err))
 		return "", err
 	}
 
@@ -685,16 +858,309 @@ func (s *Service) getFullPath(dir string, filename string) (string, error) {
 	return fullpath, nil
 }
 
+// exportTo writes every non-empty collection into its own dump
+func (s *Service) exportTo(storage Storage) error {
+	var accounts, payments, favorites, limits, holds, refunds, disputes, schedules, runs, templates, phones, moves, members, exchanges strings.Builder
+	for _, account := range s.accounts {
+		accounts.WriteString(s.parseAccountToString(account, "\n"))
+	}
+
+	for _, payment := range s.payments {
+		payments.WriteString(s.parsePaymentToString(payment))
+	}
+
+	for _, favorite := range s.favorites {
+		favorites.WriteString(s.parseFavoriteToString(favorite))
+	}
+
+	for _, limit := range s.spendingLimits {
+		limits.WriteString(s.parseSpendingLimitToString(limit))
+	}
+
+	for _, hold := range s.holds {
+		holds.WriteString(s.parseHoldToString(hold))
+	}
+
+	for _, refund := range s.refunds {
+		refunds.WriteString(s.parseRefundToString(refund))
+	}
+
+	for _, dispute := range s.disputes {
+		disputes.WriteString(s.parseDisputeToString(dispute))
+	}
+
+	for _, schedule := range s.schedules {
+		schedules.WriteString(s.parseScheduleToString(schedule))
+	}
+
+	for _, run := range s.scheduleRuns {
+		runs.WriteString(s.parseScheduleRunToString(run))
+	}
+
+	for _, template := range s.templates {
+		templates.WriteString(s.parseTemplateToString(template))
+	}
+
+	for _, record := range s.phoneHistory {
+		phones.WriteString(s.parsePhoneRecordToString(record))
+	}
+
+	for _, move := range s.pocketMoves {
+		moves.WriteString(s.parsePocketMoveToString(move))
+	}
+
+	for _, member := range s.members {
+		members.WriteString(s.parseMemberToString(member))
+	}
+
+	for _, value := range s.exchanges {
+		exchanges.WriteString(s.parseExchangeToString(value))
+	}
+
+	dumps := []struct {
+		name	string
+		data	string
+	} {
+		{ name: accountsDump,	data: accounts.String() },
+		{ name: paymentsDump,	data: payments.String() },
+		{ name: favoritesDump,	data: favorites.String() },
+		{ name: limitsDump,		data: limits.String() },
+		{ name: holdsDump,		data: holds.String() },
+		{ name: refundsDump,	data: refunds.String() },
+		{ name: disputesDump,	data: disputes.String() },
+		{ name: schedulesDump,	data: schedules.String() },
+		{ name: runsDump,		data: runs.String() },
+		{ name: templatesDump,	data: templates.String() },
+		{ name: phonesDump,		data: phones.String() },
+		{ name: movesDump,		data: moves.String() },
+		{ name: membersDump,	data: members.String() },
+		{ name: exchangesDump,	data: exchanges.String() },
+	}
+
+	for _, dump := range dumps {
+		if dump.data == "" {
+			continue
+		}
+
+		err := storage.Write(dump.name, []byte(dump.data))
+		if err != nil {
+			s.logger().Log(LevelError, "can't export", Op("export"), Path(dump.name), Err(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importFrom merges dumps into current state, broken dumps are logged and skipped
+func (s *Service) importFrom(storage Storage) error {
+	data, ok := s.readDump(storage, accountsDump)
+	if ok {
+		accounts := s.parseStringToAccounts(data, "\n")
+		for _, dumpAccount := range accounts {
+			if !s.containsAccount(dumpAccount, s.accounts) {
+				s.accounts = append(s.accounts, dumpAccount)
+				s.nextAccountID++
+			}
+		}
+
+		s.logger().Log(LevelInfo, "accounts imported", Op("import"), Path(accountsDump), Count(len(accounts)))
+	}
+
+	data, ok = s.readDump(storage, paymentsDump)
+	if ok {
+		payments := s.parseStringToPayments(data)
+		for _, dumpPayment := range payments {
+			if !s.containsPayment(dumpPayment, s.payments) {
+				s.payments = append(s.payments, dumpPayment)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "payments imported", Op("import"), Path(paymentsDump), Count(len(payments)))
+	}
+
+	data, ok = s.readDump(storage, favoritesDump)
+	if ok {
+		favorites := s.parseStringToFavorites(data)
+		for _, dumpFavorite := range favorites {
+			if !s.containsFavorite(dumpFavorite, s.favorites) {
+				s.favorites = append(s.favorites, dumpFavorite)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "favorites imported", Op("import"), Path(favoritesDump), Count(len(favorites)))
+	}
+
+	data, ok = s.readDump(storage, limitsDump)
+	if ok {
+		limits := s.parseStringToSpendingLimits(data)
+		for _, dumpLimit := range limits {
+			if !s.containsSpendingLimit(dumpLimit, s.spendingLimits) {
+				s.spendingLimits = append(s.spendingLimits, dumpLimit)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "spending limits imported", Op("import"), Path(limitsDump), Count(len(limits)))
+	}
+
+	data, ok = s.readDump(storage, holdsDump)
+	if ok {
+		holds := s.parseStringToHolds(data)
+		for _, dumpHold := range holds {
+			if !s.containsHold(dumpHold, s.holds) {
+				s.holds = append(s.holds, dumpHold)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "holds imported", Op("import"), Path(holdsDump), Count(len(holds)))
+	}
+
+	err := s.recountHeld()
+	if err != nil {
+		return err
+	}
+
+	data, ok = s.readDump(storage, refundsDump)
+	if ok {
+		refunds := s.parseStringToRefunds(data)
+		for _, dumpRefund := range refunds {
+			if !s.containsRefund(dumpRefund, s.refunds) {
+				s.refunds = append(s.refunds, dumpRefund)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "refunds imported", Op("import"), Path(refundsDump), Count(len(refunds)))
+	}
+
+	data, ok = s.readDump(storage, disputesDump)
+	if ok {
+		disputes := s.parseStringToDisputes(data)
+		for _, dumpDispute := range disputes {
+			if !s.containsDispute(dumpDispute, s.disputes) {
+				s.disputes = append(s.disputes, dumpDispute)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "disputes imported", Op("import"), Path(disputesDump), Count(len(disputes)))
+	}
+
+	data, ok = s.readDump(storage, schedulesDump)
+	if ok {
+		schedules := s.parseStringToSchedules(data)
+		for _, dumpSchedule := range schedules {
+			if !s.containsSchedule(dumpSchedule, s.schedules) {
+				s.schedules = append(s.schedules, dumpSchedule)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "schedules imported", Op("import"), Path(schedulesDump), Count(len(schedules)))
+	}
+
+	data, ok = s.readDump(storage, runsDump)
+	if ok {
+		runs := s.parseStringToScheduleRuns(data)
+		for _, dumpRun := range runs {
+			if !s.containsScheduleRun(dumpRun, s.scheduleRuns) {
+				s.scheduleRuns = append(s.scheduleRuns, dumpRun)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "schedule runs imported", Op("import"), Path(runsDump), Count(len(runs)))
+	}
+
+	data, ok = s.readDump(storage, templatesDump)
+	if ok {
+		templates := s.parseStringToTemplates(data)
+		for _, dumpTemplate := range templates {
+			if !s.containsTemplate(dumpTemplate, s.templates) {
+				s.templates = append(s.templates, dumpTemplate)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "templates imported", Op("import"), Path(templatesDump), Count(len(templates)))
+	}
+
+	data, ok = s.readDump(storage, phonesDump)
+	if ok {
+		records := s.parseStringToPhoneRecords(data)
+		for _, dumpRecord := range records {
+			if !s.containsPhoneRecord(dumpRecord, s.phoneHistory) {
+				s.phoneHistory = append(s.phoneHistory, dumpRecord)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "phone history imported", Op("import"), Path(phonesDump), Count(len(records)))
+	}
+
+	data, ok = s.readDump(storage, movesDump)
+	if ok {
+		moves := s.parseStringToPocketMoves(data)
+		for _, dumpMove := range moves {
+			if !s.containsPocketMove(dumpMove, s.pocketMoves) {
+				s.pocketMoves = append(s.pocketMoves, dumpMove)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "pocket moves imported", Op("import"), Path(movesDump), Count(len(moves)))
+	}
+
+	data, ok = s.readDump(storage, membersDump)
+	if ok {
+		members := s.parseStringToMembers(data)
+		for _, dumpMember := range members {
+			if !s.containsMember(dumpMember, s.members) {
+				s.members = append(s.members, dumpMember)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "members imported", Op("import"), Path(membersDump), Count(len(members)))
+	}
+
+	data, ok = s.readDump(storage, exchangesDump)
+	if ok {
+		exchanges := s.parseStringToExchanges(data)
+		for _, dumpExchange := range exchanges {
+			if !s.containsExchange(dumpExchange, s.exchanges) {
+				s.exchanges = append(s.exchanges, dumpExchange)
+			}
+		}
+
+		s.logger().Log(LevelInfo, "exchanges imported", Op("import"), Path(exchangesDump), Count(len(exchanges)))
+	}
+
+	return nil
+}
+
+// readDump returns content of the dump, false if it is missing, empty or can't be read
+func (s *Service) readDump(storage Storage, name string) (string, bool) {
+	content, err := storage.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false
+	}
+
+	if err != nil {
+		s.logger().Log(LevelError, "can't import", Op("import"), Path(name), Err(err))
+		return "", false
+	}
+
+	data := string(content)
+	if strings.TrimSpace(data) == "" {
+		return "", false
+	}
+
+	return data, true
+}
+
 func (s *Service) exportAccountsToFile(path string, sep string) error {
 	file, err := os.Create(path)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't write accounts", Op("export"), Path(path), Err(err))
 		return err
 	}
 
 	defer func() {
 		if err := file.Close(); err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write accounts", Op("export"), Path(path), Err(err))
 		}
 	}()
 
@@ -702,7 +1168,7 @@ func (s *Service) exportAccountsToFile(path string, sep string) error {
 		parsed := s.parseAccountToString(account, sep)
 		_, err := file.Write([]byte(parsed))
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write accounts", Op("export"), Path(path), Err(err))
 			return err
 		}
 	}
@@ -713,13 +1179,13 @@ func (s *Service) exportAccountsToFile(path string, sep string) error {
 func (s *Service) exportPaymentsToFile(path string) error {
 	file, err := os.Create(path)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't write payments", Op("export"), Path(path), Err(err))
 		return err
 	}
 
 	defer func() {
 		if err := file.Close(); err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write payments", Op("export"), Path(path), Err(err))
 		}
 	}()
 
@@ -727,7 +1193,7 @@ func (s *Service) exportPaymentsToFile(path string) error {
 		parsed := s.parsePaymentToString(payment)
 		_, err := file.Write([]byte(parsed))
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write payments", Op("export"), Path(path), Err(err))
 			return err
 		}
 	}
@@ -738,13 +1204,13 @@ func (s *Service) exportPaymentsToFile(path string) error {
 func (s *Service) exportFavoritesToFile(path string) error {
 	file, err := os.Create(path)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't write favorites", Op("export"), Path(path), Err(err))
 		return err
 	}
 
 	defer func() {
 		if err := file.Close(); err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write favorites", Op("export"), Path(path), Err(err))
 		}
 	}()
 
@@ -752,7 +1218,7 @@ func (s *Service) exportFavoritesToFile(path string) error {
 		parsed := s.parseFavoriteToString(favorite)
 		_, err := file.Write([]byte(parsed))
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't write favorites", Op("export"), Path(path), Err(err))
 			return err
 		}
 	}
@@ -805,13 +1271,13 @@ func (s *Service) importFavoritesFromFile(path string) ([]*types.Favorite, error
 func (s *Service) getDataFromFile(path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		log.Println(err)
+		s.logger().Log(LevelError, "can't read file", Op("import"), Path(path), Err(err))
 		return "", err
 	}
 
 	defer func() {
 		if err := file.Close(); err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't read file", Op("import"), Path(path), Err(err))
 		}
 	}()
 
@@ -825,7 +1291,7 @@ func (s *Service) getDataFromFile(path string) (string, error) {
 		}
 
 		if err != nil {
-			log.Println(err)
+			s.logger().Log(LevelError, "can't read file", Op("import"), Path(path), Err(err))
 			return "", err
 		}
 
@@ -839,9 +1305,33 @@ func (s *Service) getDataFromFile(path string) (string, error) {
 func (s *Service) parseAccountToString(account *types.Account, sep string) string {
 	parsed := strconv.FormatInt(account.ID, 10) + ";"
 	parsed += string(account.Phone) + ";"
-	parsed += strconv.FormatInt(int64(account.Balance), 10) + sep
-	
-	return parsed
+	parsed += strconv.FormatInt(int64(account.Balance), 10)
+
+	// status is written only for frozen and closed accounts, pockets and accounts in other currencies
+	status := account.Status
+	if status == "" {
+		status = types.AccountStatusActive
+	}
+
+	currency := AccountCurrency(account)
+	foreign := currency != DefaultCurrency
+
+	if status != types.AccountStatusActive || account.CustomerID != 0 || foreign {
+		parsed += ";" + string(status)
+		parsed += ";" + strconv.FormatInt(account.StatusChangedAt, 10)
+		parsed += ";" + url.QueryEscape(account.StatusReason)
+	}
+
+	if account.CustomerID != 0 || foreign {
+		parsed += ";" + strconv.FormatInt(account.CustomerID, 10)
+		parsed += ";" + url.QueryEscape(account.Pocket)
+	}
+
+	if foreign {
+		parsed += ";" + string(currency)
+	}
+
+	return parsed + sep
 }
 
 func (s *Service) parseStringToAccounts(data string, sep string) []*types.Account {
@@ -869,6 +1359,29 @@ func (s *Service) parseStringToAccounts(data string, sep string) []*types.Accoun
 				ID:			id,
 				Phone:		phone,
 				Balance:	types.Money(balance),
+				Status:		types.AccountStatusActive,
+				Currency:	DefaultCurrency,
+			}
+
+			if len(item) >= 6 {
+				changedAt, _	:= strconv.ParseInt(item[4], 10, 64)
+				reason, _		:= url.QueryUnescape(item[5])
+
+				account.Status			= types.AccountStatus(item[3])
+				account.StatusChangedAt	= changedAt
+				account.StatusReason	= reason
+			}
+
+			if len(item) >= 8 {
+				customerID, _	:= strconv.ParseInt(item[6], 10, 64)
+				pocket, _		:= url.QueryUnescape(item[7])
+
+				account.CustomerID	= customerID
+				account.Pocket		= pocket
+			}
+
+			if len(item) >= 9 {
+				account.Currency = types.Currency(item[8])
 			}
 
 			accounts = append(accounts, account)
@@ -883,7 +1396,29 @@ func (s *Service) parsePaymentToString(payment *types.Payment) string {
 	parsed += strconv.FormatInt(payment.AccountID, 10) + ";"
 	parsed += strconv.FormatInt(int64(payment.Amount), 10) + ";"
 	parsed += string(payment.Category) + ";"
-	parsed += string(payment.Status) + "\n"
+	parsed += string(payment.Status) + ";"
+	parsed += strconv.FormatInt(payment.CreatedAt, 10)
+
+	// params, member and currency columns are written only when set to keep other lines unchanged
+	currency := PaymentCurrency(payment)
+	foreign := currency != DefaultCurrency
+
+	if len(payment.Params) > 0 || payment.InitiatedBy != "" || foreign {
+		params := url.Values{}
+		for name, value := range payment.Params {
+			params.Set(name, value)
+		}
+		parsed += ";" + params.Encode()
+	}
+
+	if payment.InitiatedBy != "" || foreign {
+		parsed += ";" + string(payment.InitiatedBy)
+	}
+
+	if foreign {
+		parsed += ";" + string(currency)
+	}
+	parsed += "\n"
 
 	return parsed
 }
@@ -907,6 +1442,29 @@ func (s *Service) parseStringToPayments(data string) []*types.Payment {
 			Amount:			types.Money(amount),
 			Category:		types.PaymentCategory(category),
 			Status:			types.PaymentStatus(status),
+			Currency:		DefaultCurrency,
+		}
+
+		if len(item) > 5 {
+			payment.CreatedAt, _ = strconv.ParseInt(item[5], 10, 64)
+		}
+
+		if len(item) > 6 {
+			params, _ := url.ParseQuery(item[6])
+			if len(params) > 0 {
+				payment.Params = make(map[string]string, len(params))
+				for name := range params {
+					payment.Params[name] = params.Get(name)
+				}
+			}
+		}
+
+		if len(item) > 7 {
+			payment.InitiatedBy = types.Phone(item[7])
+		}
+
+		if len(item) > 8 {
+			payment.Currency = types.Currency(item[8])
 		}
 
 		payments = append(payments, payment)
@@ -918,9 +1476,12 @@ func (s *Service) parseStringToPayments(data string) []*types.Payment {
 func (s *Service) parseFavoriteToString(favorite *types.Favorite) string {
 	parsed := favorite.ID + ";"
 	parsed += strconv.FormatInt(favorite.AccountID, 10) + ";"
-	parsed += favorite.Name + ";"
+	parsed += url.PathEscape(favorite.Name) + ";"
 	parsed += strconv.FormatInt(int64(favorite.Amount), 10) + ";"
-	parsed += string(favorite.Category) + "\n"
+	parsed += string(favorite.Category) + ";"
+	parsed += strconv.Itoa(favorite.UsageCount) + ";"
+	parsed += strconv.FormatInt(favorite.LastUsed, 10) + ";"
+	parsed += strconv.Itoa(favorite.Position) + "\n"
 
 	return parsed
 }
@@ -934,7 +1495,7 @@ func (s *Service) parseStringToFavorites(data string) []*types.Favorite {
 
 		id			 	:= string(item[0])
 		accountID, _ 	:= strconv.ParseInt(item[1], 10, 64)
-		name			:= string(item[2])
+		name			:= unescapeName(item[2])
 		amount, _ 		:= strconv.ParseInt(item[3], 10, 64)
 		category		:= string(item[4])
 
@@ -946,17 +1507,40 @@ func (s *Service) parseStringToFavorites(data string) []*types.Favorite {
 			Category:		types.PaymentCategory(category),
 		}
 
+		// usage and position are missing in older dumps
+		if len(item) >= 8 {
+			favorite.UsageCount, _	= strconv.Atoi(item[5])
+			favorite.LastUsed, _	= strconv.ParseInt(item[6], 10, 64)
+			favorite.Position, _	= strconv.Atoi(item[7])
+		}
+
 		favorites = append(favorites, favorite)
 	}
 
 	return favorites
 }
 
+// unescapeName decodes name escaped in dumps, names written unescaped by older dumps are kept as is
+func unescapeName(value string) string {
+	name, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+
+	return name
+}
+
 func (s *Service) containsAccount(item *types.Account, items []*types.Account) bool {
 	for _, value := range items {
 		if value.ID == item.ID {
-			value.Phone 	= item.Phone
-			value.Balance 	= item.Balance
+			value.Phone 			= item.Phone
+			value.Balance 			= item.Balance
+			value.Status			= item.Status
+			value.StatusReason		= item.StatusReason
+			value.StatusChangedAt	= item.StatusChangedAt
+			value.CustomerID		= item.CustomerID
+			value.Pocket			= item.Pocket
+			value.Currency			= item.Currency
 
 			return true
 		}
@@ -972,6 +1556,10 @@ func (s *Service) containsPayment(item *types.Payment, items []*types.Payment) b
 			value.Amount 		= item.Amount
 			value.Category	 	= item.Category
 			value.Status 		= item.Status
+			value.CreatedAt		= item.CreatedAt
+			value.Params		= item.Params
+			value.InitiatedBy	= item.InitiatedBy
+			value.Currency		= item.Currency
 
 			return true
 		}
@@ -987,6 +1575,9 @@ func (s *Service) containsFavorite(item *types.Favorite, items []*types.Favorite
 			value.Name 			= item.Name
 			value.Amount 		= item.Amount
 			value.Category	 	= item.Category
+			value.UsageCount	= item.UsageCount
+			value.LastUsed		= item.LastUsed
+			value.Position		= item.Position
 
 			return true
 		}
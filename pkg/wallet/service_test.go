@@ -822,7 +822,7 @@ func TestService_ParsePaymentToString(t *testing.T) {
 		Status:			types.PaymentStatusOk,
 	}
 
-	expected := "1;1;10;auto;OK"
+	expected := "1;1;10;auto;OK;0"
 	result := strings.TrimSpace(s.parsePaymentToString(payment))
 	
 	if result != expected {
@@ -839,6 +839,7 @@ func TestService_ParseStringToPayment(t *testing.T) {
 		Amount:			10,
 		Category:		"auto",
 		Status:			types.PaymentStatusOk,
+		Currency:		DefaultCurrency,
 	}
 
 	data := "1;1;10;auto;OK"
@@ -860,7 +861,7 @@ func TestService_ParseFavoriteToString(t *testing.T) {
 		Category:		"auto",
 	}
 
-	expected := "1;1;auto;10;auto"
+	expected := "1;1;auto;10;auto;0;0;0"
 	result := strings.TrimSpace(s.parseFavoriteToString(favorite))
 	
 	if result != expected {
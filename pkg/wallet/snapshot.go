@@ -0,0 +1,161 @@
+package wallet
+
+import (
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// Snapshot - saved state of the service used to roll back a group of operations
+type Snapshot struct {
+	nextAccountID	int64
+	accounts		[]*types.Account
+	accountValues	[]types.Account
+	payments		[]*types.Payment
+	paymentValues	[]types.Payment
+	favorites		[]*types.Favorite
+	favoriteValues	[]types.Favorite
+	spendingLimits	[]*types.SpendingLimit
+	limitValues		[]types.SpendingLimit
+	holds			[]*types.Hold
+	holdValues		[]types.Hold
+	refunds			[]*types.Refund
+	disputes		[]*types.Dispute
+	disputeValues	[]types.Dispute
+	schedules		[]*types.Schedule
+	scheduleValues	[]types.Schedule
+	scheduleRuns	[]*types.ScheduleRun
+	templates		[]*types.Template
+	templateValues	[]types.Template
+	phoneHistory	[]*types.PhoneRecord
+	pocketMoves		[]*types.PocketMove
+	members			[]*types.Member
+	exchanges		[]*types.Exchange
+	exchangeValues	[]types.Exchange
+}
+
+// Snapshot saves current state of the service
+func (s *Service) Snapshot() *Snapshot {
+	snapshot := &Snapshot {
+		nextAccountID:	s.nextAccountID,
+		accounts:		append([]*types.Account(nil), s.accounts...),
+		payments:		append([]*types.Payment(nil), s.payments...),
+		favorites:		append([]*types.Favorite(nil), s.favorites...),
+		holds:			append([]*types.Hold(nil), s.holds...),
+		refunds:		append([]*types.Refund(nil), s.refunds...),
+		disputes:		append([]*types.Dispute(nil), s.disputes...),
+		schedules:		append([]*types.Schedule(nil), s.schedules...),
+		scheduleRuns:	append([]*types.ScheduleRun(nil), s.scheduleRuns...),
+		templates:		append([]*types.Template(nil), s.templates...),
+		phoneHistory:	append([]*types.PhoneRecord(nil), s.phoneHistory...),
+		pocketMoves:	append([]*types.PocketMove(nil), s.pocketMoves...),
+		members:		append([]*types.Member(nil), s.members...),
+		exchanges:		append([]*types.Exchange(nil), s.exchanges...),
+	}
+
+	for _, account := range s.accounts {
+		snapshot.accountValues = append(snapshot.accountValues, *account)
+	}
+
+	for _, payment := range s.payments {
+		snapshot.paymentValues = append(snapshot.paymentValues, *payment)
+	}
+
+	for _, favorite := range s.favorites {
+		snapshot.favoriteValues = append(snapshot.favoriteValues, *favorite)
+	}
+
+	snapshot.spendingLimits = append([]*types.SpendingLimit(nil), s.spendingLimits...)
+	for _, limit := range s.spendingLimits {
+		value := *limit
+		value.Categories = make(map[types.PaymentCategory]types.Money)
+		for category, amount := range limit.Categories {
+			value.Categories[category] = amount
+		}
+		snapshot.limitValues = append(snapshot.limitValues, value)
+	}
+
+	for _, hold := range s.holds {
+		snapshot.holdValues = append(snapshot.holdValues, *hold)
+	}
+
+	for _, dispute := range s.disputes {
+		value := *dispute
+		value.Events = append([]types.DisputeEvent(nil), dispute.Events...)
+		snapshot.disputeValues = append(snapshot.disputeValues, value)
+	}
+
+	for _, schedule := range s.schedules {
+		snapshot.scheduleValues = append(snapshot.scheduleValues, *schedule)
+	}
+
+	for _, template := range s.templates {
+		value := *template
+		value.Fields = append([]types.TemplateField(nil), template.Fields...)
+		snapshot.templateValues = append(snapshot.templateValues, value)
+	}
+
+	for _, value := range s.exchanges {
+		snapshot.exchangeValues = append(snapshot.exchangeValues, *value)
+	}
+
+	return snapshot
+}
+
+// Restore brings the service back to the snapshot, objects returned before
+// the snapshot was taken keep pointing to the restored state
+func (s *Service) Restore(snapshot *Snapshot) {
+	s.nextAccountID = snapshot.nextAccountID
+
+	s.accounts = append([]*types.Account(nil), snapshot.accounts...)
+	for i, account := range s.accounts {
+		*account = snapshot.accountValues[i]
+	}
+
+	s.payments = append([]*types.Payment(nil), snapshot.payments...)
+	for i, payment := range s.payments {
+		*payment = snapshot.paymentValues[i]
+	}
+
+	s.favorites = append([]*types.Favorite(nil), snapshot.favorites...)
+	for i, favorite := range s.favorites {
+		*favorite = snapshot.favoriteValues[i]
+	}
+
+	s.spendingLimits = append([]*types.SpendingLimit(nil), snapshot.spendingLimits...)
+	for i, limit := range s.spendingLimits {
+		*limit = snapshot.limitValues[i]
+	}
+
+	s.holds = append([]*types.Hold(nil), snapshot.holds...)
+	for i, hold := range s.holds {
+		*hold = snapshot.holdValues[i]
+	}
+
+	// refunds, schedule runs, phone records, pocket moves and members are never changed, restoring the lists is enough
+	s.refunds = append([]*types.Refund(nil), snapshot.refunds...)
+
+	s.disputes = append([]*types.Dispute(nil), snapshot.disputes...)
+	for i, dispute := range s.disputes {
+		*dispute = snapshot.disputeValues[i]
+	}
+
+	s.schedules = append([]*types.Schedule(nil), snapshot.schedules...)
+	for i, schedule := range s.schedules {
+		*schedule = snapshot.scheduleValues[i]
+	}
+
+	s.scheduleRuns = append([]*types.ScheduleRun(nil), snapshot.scheduleRuns...)
+
+	s.templates = append([]*types.Template(nil), snapshot.templates...)
+	for i, template := range s.templates {
+		*template = snapshot.templateValues[i]
+	}
+
+	s.phoneHistory = append([]*types.PhoneRecord(nil), snapshot.phoneHistory...)
+	s.pocketMoves = append([]*types.PocketMove(nil), snapshot.pocketMoves...)
+	s.members = append([]*types.Member(nil), snapshot.members...)
+
+	s.exchanges = append([]*types.Exchange(nil), snapshot.exchanges...)
+	for i, value := range s.exchanges {
+		*value = snapshot.exchangeValues[i]
+	}
+}
@@ -0,0 +1,58 @@
+package wallet
+
+import (
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+	"testing"
+)
+
+func TestService_Restore_success(t *testing.T) {
+	s := newTestService()
+
+	account, payments, _, err := s.addAcount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	snapshot := s.Snapshot()
+
+	err = s.Reject(payments[0].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.addAccountWithBalance("+992937452946", 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s.Restore(snapshot)
+
+	if len(s.accounts) != 1 || len(s.payments) != 1 || len(s.favorites) != 1 {
+		t.Errorf("Restore(): wrong state restored, accounts = %v, payments = %v", len(s.accounts), len(s.payments))
+		return
+	}
+
+	if account.Balance != defaultTestAccount.balance - payments[0].Amount {
+		t.Errorf("Restore(): balance didn't restored, account = %v", account)
+		return
+	}
+
+	if payments[0].Status == types.PaymentStatusFail {
+		t.Errorf("Restore(): status didn't restored, payment = %v", payments[0])
+		return
+	}
+
+	next, err := s.RegisterAccount("+992937452947")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if next.ID != 2 {
+		t.Errorf("Restore(): account id sequence didn't restored, id = %v", next.ID)
+		return
+	}
+}
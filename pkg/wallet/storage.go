@@ -0,0 +1,77 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Storage - place where Export and Import keep dump files
+type Storage interface {
+	// Read returns content of the dump, error matches os.ErrNotExist if there is no such dump
+	Read(name string) ([]byte, error)
+	Write(name string, data []byte) error
+}
+
+// DirStorage keeps dumps as files in the directory
+type DirStorage struct {
+	dir		string
+}
+
+// NewDirStorage creates storage in the directory, it is created on the first write
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{ dir: dir }
+}
+
+// Dir returns directory of the storage
+func (d *DirStorage) Dir() string {
+	return d.dir
+}
+
+// Read returns content of the file
+func (d *DirStorage) Read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(d.dir, name))
+}
+
+// Write replaces content of the file
+func (d *DirStorage) Write(name string, data []byte) error {
+	err := os.MkdirAll(d.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(d.dir, name), data, 0666)
+}
+
+// MemoryStorage keeps dumps in memory, useful for tests
+type MemoryStorage struct {
+	mu		sync.Mutex
+	files	map[string][]byte
+}
+
+// Read returns copy of the dump
+func (m *MemoryStorage) Read(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &os.PathError{ Op: "read", Path: name, Err: os.ErrNotExist }
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+// Write saves copy of the dump
+func (m *MemoryStorage) Write(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.files == nil {
+		m.files = make(map[string][]byte)
+	}
+
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,287 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrTemplateNotFound - template does not exist
+var ErrTemplateNotFound = errors.New("Template not found")
+
+// ErrInvalidTemplate - template has invalid amounts or fields
+var ErrInvalidTemplate = errors.New("Invalid template")
+
+// ErrInvalidTemplateAmount - amount does not fit the template
+var ErrInvalidTemplateAmount = errors.New("Amount does not fit the template")
+
+// ErrInvalidTemplateParams - parameters are missing, unknown or malformed
+var ErrInvalidTemplateParams = errors.New("Invalid template parameters")
+
+// reservedParams - params of exchanges, transfers and fees, template fields can't use them
+var reservedParams = map[string]bool {
+	"exchange":	true,
+	"transfer":	true,
+	"from":		true,
+	"to":		true,
+	"fee":		true,
+}
+
+// CreateTemplate saves template of the account, fixed amount excludes bounds
+func (s *Service) CreateTemplate(template types.Template) (*types.Template, error) {
+	_, err := s.findOpenAccount(template.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(template.Name) == "" {
+		return nil, fmt.Errorf("%w: name is empty", ErrInvalidTemplate)
+	}
+
+	for _, saved := range s.templates {
+		if saved.AccountID == template.AccountID && saved.Name == template.Name {
+			return nil, fmt.Errorf("%w: name %q is taken", ErrInvalidTemplate, template.Name)
+		}
+	}
+
+	if template.Amount < 0 || template.MinAmount < 0 || template.MaxAmount < 0 {
+		return nil, fmt.Errorf("%w: amounts must not be negative", ErrInvalidTemplate)
+	}
+
+	if template.Amount > 0 && (template.MinAmount > 0 || template.MaxAmount > 0) {
+		return nil, fmt.Errorf("%w: fixed amount can't have bounds", ErrInvalidTemplate)
+	}
+
+	if template.MaxAmount > 0 && template.MinAmount > template.MaxAmount {
+		return nil, fmt.Errorf("%w: min amount is more than max amount", ErrInvalidTemplate)
+	}
+
+	names := make(map[string]bool)
+	for _, field := range template.Fields {
+		if field.Name == "" || names[field.Name] {
+			return nil, fmt.Errorf("%w: field name %q is empty or repeated", ErrInvalidTemplate, field.Name)
+		}
+		names[field.Name] = true
+
+		if reservedParams[field.Name] {
+			return nil, fmt.Errorf("%w: field name %q is reserved", ErrInvalidTemplate, field.Name)
+		}
+
+		_, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %q: %v", ErrInvalidTemplate, field.Name, err)
+		}
+	}
+
+	template.ID = s.newID()
+	template.Fields = append([]types.TemplateField(nil), template.Fields...)
+
+	s.templates = append(s.templates, &template)
+	s.logger().Log(LevelInfo, "template created", Op("template"), AccountID(template.AccountID), Field{ Key: "template", Value: template.ID })
+
+	return &template, nil
+}
+
+// FindTemplateByID returns template by id
+func (s *Service) FindTemplateByID(templateID string) (*types.Template, error) {
+	for _, template := range s.templates {
+		if template.ID == templateID {
+			return template, nil
+		}
+	}
+
+	return nil, ErrTemplateNotFound
+}
+
+// FindTemplatesByAccountID returns templates of the account
+func (s *Service) FindTemplatesByAccountID(accountID int64) []*types.Template {
+	var templates []*types.Template
+	for _, template := range s.templates {
+		if template.AccountID == accountID {
+			templates = append(templates, template)
+		}
+	}
+
+	return templates
+}
+
+// DeleteTemplate removes the template, payments made from it are kept
+func (s *Service) DeleteTemplate(templateID string) error {
+	template, err := s.FindTemplateByID(templateID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.findOpenAccount(template.AccountID)
+	if err != nil {
+		return err
+	}
+
+	for i, value := range s.templates {
+		if value == template {
+			s.templates = append(s.templates[:i], s.templates[i + 1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// PayFromTemplate pays by the template, amount is ignored if the template has fixed
+// one, params are validated by template fields and saved on the payment
+func (s *Service) PayFromTemplate(templateID string, amount types.Money, params map[string]string) (*types.Payment, error) {
+	template, err := s.FindTemplateByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.Amount > 0 {
+		amount = template.Amount
+	}
+
+	if template.MinAmount > 0 && amount < template.MinAmount {
+		return nil, fmt.Errorf("%w: amount %d is less than %d", ErrInvalidTemplateAmount, amount, template.MinAmount)
+	}
+
+	if template.MaxAmount > 0 && amount > template.MaxAmount {
+		return nil, fmt.Errorf("%w: amount %d is more than %d", ErrInvalidTemplateAmount, amount, template.MaxAmount)
+	}
+
+	err = validateParams(template, params)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.Pay(template.AccountID, amount, template.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params) > 0 {
+		payment.Params = make(map[string]string, len(params))
+		for name, value := range params {
+			payment.Params[name] = value
+		}
+	}
+
+	return payment, nil
+}
+
+func validateParams(template *types.Template, params map[string]string) error {
+	fields := make(map[string]types.TemplateField, len(template.Fields))
+	for _, field := range template.Fields {
+		fields[field.Name] = field
+	}
+
+	for name := range params {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("%w: unknown parameter %q", ErrInvalidTemplateParams, name)
+		}
+	}
+
+	for _, field := range template.Fields {
+		value, ok := params[field.Name]
+		if !ok || value == "" {
+			if field.Required {
+				return fmt.Errorf("%w: parameter %q is required", ErrInvalidTemplateParams, field.Name)
+			}
+			continue
+		}
+
+		if field.Pattern == "" {
+			continue
+		}
+
+		matched, err := regexp.MatchString("^(?:" + field.Pattern + ")$", value)
+		if err != nil || !matched {
+			return fmt.Errorf("%w: parameter %q does not match %s", ErrInvalidTemplateParams, field.Name, field.Pattern)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) parseTemplateToString(template *types.Template) string {
+	fields := make([]string, 0, len(template.Fields))
+	for _, field := range template.Fields {
+		required := "0"
+		if field.Required {
+			required = "1"
+		}
+		fields = append(fields, url.QueryEscape(field.Name) + ":" + required + ":" + url.QueryEscape(field.Pattern))
+	}
+
+	parsed := template.ID + ";"
+	parsed += strconv.FormatInt(template.AccountID, 10) + ";"
+	parsed += url.QueryEscape(template.Name) + ";"
+	parsed += string(template.Category) + ";"
+	parsed += strconv.FormatInt(int64(template.Amount), 10) + ";"
+	parsed += strconv.FormatInt(int64(template.MinAmount), 10) + ";"
+	parsed += strconv.FormatInt(int64(template.MaxAmount), 10) + ";"
+	parsed += strings.Join(fields, ",") + "\n"
+
+	return parsed
+}
+
+func (s *Service) parseStringToTemplates(data string) []*types.Template {
+	var templates []*types.Template
+
+	data = strings.TrimSpace(data)
+	for _, items := range strings.Split(data, "\n") {
+		item := strings.Split(items, ";")
+		if len(item) < 8 {
+			continue
+		}
+
+		accountID, _	:= strconv.ParseInt(item[1], 10, 64)
+		name, _			:= url.QueryUnescape(item[2])
+		amount, _		:= strconv.ParseInt(item[4], 10, 64)
+		minAmount, _	:= strconv.ParseInt(item[5], 10, 64)
+		maxAmount, _	:= strconv.ParseInt(item[6], 10, 64)
+
+		template := &types.Template {
+			ID:			item[0],
+			AccountID:	accountID,
+			Name:		name,
+			Category:	types.PaymentCategory(item[3]),
+			Amount:		types.Money(amount),
+			MinAmount:	types.Money(minAmount),
+			MaxAmount:	types.Money(maxAmount),
+		}
+
+		for _, value := range strings.Split(item[7], ",") {
+			parts := strings.Split(value, ":")
+			if len(parts) != 3 {
+				continue
+			}
+
+			fieldName, _	:= url.QueryUnescape(parts[0])
+			pattern, _		:= url.QueryUnescape(parts[2])
+
+			template.Fields = append(template.Fields, types.TemplateField {
+				Name:		fieldName,
+				Required:	parts[1] == "1",
+				Pattern:	pattern,
+			})
+		}
+
+		templates = append(templates, template)
+	}
+
+	return templates
+}
+
+func (s *Service) containsTemplate(item *types.Template, items []*types.Template) bool {
+	for _, value := range items {
+		if value.ID == item.ID {
+			*value = *item
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,233 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func (s *testService) addTemplate(account *types.Account) (*types.Template, error) {
+	template, err := s.CreateTemplate(types.Template {
+		AccountID:	account.ID,
+		Name:		"electricity",
+		Category:	"utilities",
+		MinAmount:	100,
+		MaxAmount:	5_000,
+		Fields:		[]types.TemplateField {
+			{ Name: "customer", Required: true, Pattern: `\d{8}` },
+			{ Name: "comment" },
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create template, error = %v", err)
+	}
+
+	return template, nil
+}
+
+func TestService_CreateTemplate_fail(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.addTemplate(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name		string
+		template	types.Template
+		want		error
+	} {
+		{ name: "no account",		template: types.Template{ AccountID: 100, Name: "gas" },			want: ErrAccountNotFound },
+		{ name: "no name",			template: types.Template{ AccountID: account.ID },					want: ErrInvalidTemplate },
+		{ name: "taken name",		template: types.Template{ AccountID: account.ID, Name: "electricity" },	want: ErrInvalidTemplate },
+		{ name: "fixed and bounds",	template: types.Template{ AccountID: account.ID, Name: "gas", Amount: 10, MaxAmount: 20 },	want: ErrInvalidTemplate },
+		{ name: "min over max",		template: types.Template{ AccountID: account.ID, Name: "gas", MinAmount: 30, MaxAmount: 20 },	want: ErrInvalidTemplate },
+		{
+			name:		"repeated field",
+			template:	types.Template{ AccountID: account.ID, Name: "gas", Fields: []types.TemplateField{ { Name: "a" }, { Name: "a" } } },
+			want:		ErrInvalidTemplate,
+		},
+		{
+			name:		"reserved field",
+			template:	types.Template{ AccountID: account.ID, Name: "gas", Fields: []types.TemplateField{ { Name: "to" } } },
+			want:		ErrInvalidTemplate,
+		},
+		{
+			name:		"bad pattern",
+			template:	types.Template{ AccountID: account.ID, Name: "gas", Fields: []types.TemplateField{ { Name: "a", Pattern: "(" } } },
+			want:		ErrInvalidTemplate,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := s.CreateTemplate(test.template)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: CreateTemplate(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestService_PayFromTemplate_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	template, err := s.addTemplate(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayFromTemplate(template.ID, 1_250, map[string]string{ "customer": "12345678", "comment": "march; flat 5" })
+	if err != nil {
+		t.Errorf("PayFromTemplate(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 1_250 || payment.Category != "utilities" || payment.Params["customer"] != "12345678" {
+		t.Errorf("PayFromTemplate(): wrong payment = %+v", payment)
+		return
+	}
+
+	if account.Balance != 8_750 {
+		t.Errorf("PayFromTemplate(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	fixed, err := s.CreateTemplate(types.Template{ AccountID: account.ID, Name: "internet", Category: "isp", Amount: 300 })
+	if err != nil {
+		t.Errorf("CreateTemplate(): error = %v", err)
+		return
+	}
+
+	payment, err = s.PayFromTemplate(fixed.ID, 0, nil)
+	if err != nil {
+		t.Errorf("PayFromTemplate(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 300 || payment.Params != nil {
+		t.Errorf("PayFromTemplate(): fixed amount must be used, payment = %+v", payment)
+		return
+	}
+}
+
+func TestService_PayFromTemplate_fail(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	template, err := s.addTemplate(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		amount	types.Money
+		params	map[string]string
+		want	error
+	} {
+		{ name: "below min",	amount: 99,		params: map[string]string{ "customer": "12345678" },	want: ErrInvalidTemplateAmount },
+		{ name: "above max",	amount: 5_001,	params: map[string]string{ "customer": "12345678" },	want: ErrInvalidTemplateAmount },
+		{ name: "missing",		amount: 100,	params: map[string]string{ "comment": "x" },			want: ErrInvalidTemplateParams },
+		{ name: "malformed",	amount: 100,	params: map[string]string{ "customer": "1234567" },		want: ErrInvalidTemplateParams },
+		{ name: "suffix",		amount: 100,	params: map[string]string{ "customer": "12345678x" },	want: ErrInvalidTemplateParams },
+		{ name: "unknown",		amount: 100,	params: map[string]string{ "customer": "12345678", "x": "1" },	want: ErrInvalidTemplateParams },
+	}
+
+	for _, test := range tests {
+		_, err := s.PayFromTemplate(template.ID, test.amount, test.params)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: PayFromTemplate(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+
+	if account.Balance != 10_000 {
+		t.Errorf("PayFromTemplate(): failed payments must not change balance = %v", account.Balance)
+		return
+	}
+
+	err = s.DeleteTemplate(template.ID)
+	if err != nil {
+		t.Errorf("DeleteTemplate(): error = %v", err)
+		return
+	}
+
+	_, err = s.PayFromTemplate(template.ID, 100, nil)
+	if err != ErrTemplateNotFound {
+		t.Errorf("PayFromTemplate(): must return ErrTemplateNotFound, but error = %v", err)
+		return
+	}
+}
+
+func TestService_Export_templates(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	template, err := s.addTemplate(account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayFromTemplate(template.ID, 500, map[string]string{ "customer": "12345678", "comment": "a=b&c;d" })
+	if err != nil {
+		t.Errorf("PayFromTemplate(): error = %v", err)
+		return
+	}
+
+	storage := &MemoryStorage{}
+	err = s.exportTo(storage)
+	if err != nil {
+		t.Errorf("exportTo(): error = %v", err)
+		return
+	}
+
+	imported := &Service{}
+	err = imported.importFrom(storage)
+	if err != nil {
+		t.Errorf("importFrom(): error = %v", err)
+		return
+	}
+
+	templates := imported.FindTemplatesByAccountID(account.ID)
+	if len(templates) != 1 || templates[0].MaxAmount != 5_000 || len(templates[0].Fields) != 2 || templates[0].Fields[0] != template.Fields[0] {
+		t.Errorf("importFrom(): wrong templates = %+v", templates)
+		return
+	}
+
+	restored, err := imported.FindPaymentByID(payment.ID)
+	if err != nil {
+		t.Errorf("FindPaymentByID(): error = %v", err)
+		return
+	}
+
+	if len(restored.Params) != 2 || restored.Params["comment"] != "a=b&c;d" {
+		t.Errorf("importFrom(): wrong params = %v", restored.Params)
+		return
+	}
+}
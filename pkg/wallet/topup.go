@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/operator"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrOperatorNotFound - no operator serves the phone number
+var ErrOperatorNotFound = operator.ErrOperatorNotFound
+
+// ErrInvalidTopUpAmount - amount is out of the operator bounds
+var ErrInvalidTopUpAmount = errors.New("Amount is out of the operator bounds")
+
+// TopUpCategory - category of mobile top-up payments
+const TopUpCategory types.PaymentCategory = "mobile"
+
+// TopUpCurrency - currency of operator bounds and top-up payments
+const TopUpCurrency types.Currency = "TJS"
+
+// TopUp pays for the phone of the operator resolved by its number, the phone and
+// the operator are saved in payment params
+func (s *Service) TopUp(accountID int64, number types.Phone, amount types.Money) (*types.Payment, error) {
+	if s.currencyOf(accountID) != TopUpCurrency {
+		return nil, fmt.Errorf("%w: top-ups are paid in %s", ErrCurrencyMismatch, TopUpCurrency)
+	}
+
+	normalized, err := s.phoneTable().Normalize(string(number))
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.operatorRegistry().Resolve(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount < resolved.MinAmount || (resolved.MaxAmount > 0 && amount > resolved.MaxAmount) {
+		return nil, fmt.Errorf("%w: %s accepts %d to %d", ErrInvalidTopUpAmount, resolved.Name, resolved.MinAmount, resolved.MaxAmount)
+	}
+
+	payment, err := s.Pay(accountID, amount, TopUpCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.Params = map[string]string {
+		"phone":	string(normalized),
+		"operator":	resolved.Name,
+	}
+
+	return payment, nil
+}
+
+// LoadOperators replaces operator registry by the file, see operator.Load for its format
+func (s *Service) LoadOperators(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	registry, err := operator.Load(file)
+	if err != nil {
+		return err
+	}
+
+	s.operators = registry
+	s.logger().Log(LevelInfo, "operators loaded", Op("operators"), Path(path))
+
+	return nil
+}
+
+func (s *Service) operatorRegistry() *operator.Registry {
+	if s.operators == nil {
+		return operator.Default
+	}
+
+	return s.operators
+}
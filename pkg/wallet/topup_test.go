@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+func TestService_TopUp_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.TopUp(account.ID, "93 745 29 45", 20_00)
+	if err != nil {
+		t.Errorf("TopUp(): error = %v", err)
+		return
+	}
+
+	if payment.Category != TopUpCategory || payment.Params["operator"] != "Tcell" || payment.Params["phone"] != "+992937452945" {
+		t.Errorf("TopUp(): wrong payment = %+v", payment)
+		return
+	}
+
+	if account.Balance != 9_980_00 {
+		t.Errorf("TopUp(): wrong balance = %v", account.Balance)
+		return
+	}
+}
+
+func TestService_TopUp_fail(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name	string
+		phone	types.Phone
+		amount	types.Money
+		want	error
+	} {
+		{ name: "invalid phone",	phone: "12345",				amount: 20_00,		want: ErrInvalidPhone },
+		{ name: "no operator",		phone: "+992117452945",		amount: 20_00,		want: ErrOperatorNotFound },
+		{ name: "below min",		phone: "+992937452945",		amount: 50,			want: ErrInvalidTopUpAmount },
+		{ name: "above max",		phone: "+992557452945",		amount: 3_000_01,	want: ErrInvalidTopUpAmount },
+		{ name: "no account",		phone: "+992937452945",		amount: 20_00,		want: ErrAccountNotFound },
+	}
+
+	for _, test := range tests {
+		accountID := account.ID
+		if test.want == ErrAccountNotFound {
+			accountID = 100
+		}
+
+		_, err := s.TopUp(accountID, test.phone, test.amount)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: TopUp(): must return %v, but error = %v", test.name, test.want, err)
+		}
+	}
+
+	if len(s.GetPayments()) != 0 {
+		t.Errorf("TopUp(): failed top-ups must not create payments = %v", s.GetPayments())
+		return
+	}
+}
+
+func TestService_LoadOperators_success(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "operators.txt")
+	err = ioutil.WriteFile(path, []byte("operator;Local;100;1000\nrange;99293;99293;Local\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.LoadOperators(path)
+	if err != nil {
+		t.Errorf("LoadOperators(): error = %v", err)
+		return
+	}
+
+	payment, err := s.TopUp(account.ID, "+992937452945", 500)
+	if err != nil || payment.Params["operator"] != "Local" {
+		t.Errorf("TopUp(): wrong payment = %v, error = %v", payment, err)
+		return
+	}
+
+	_, err = s.TopUp(account.ID, "+992927452945", 500)
+	if !errors.Is(err, ErrOperatorNotFound) {
+		t.Errorf("TopUp(): table must be replaced, but error = %v", err)
+		return
+	}
+}
@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"github.com/ibrohimkhan/wallet/v1.1.0/pkg/types"
+)
+
+// ErrTransferToSelf - source and target of the transfer are the same account
+var ErrTransferToSelf = errors.New("Can't transfer to the same account")
+
+// Categories of payments made by transfers, credits are recorded on the target account
+const (
+	TransferCategory		types.PaymentCategory = "transfer"
+	TransferCreditCategory	types.PaymentCategory = "transfer_credit"
+)
+
+// Transfer moves amount to another account in the same currency, the transfer is checked
+// against spending limits of the source and fee of the schedule is debited on top of the amount.
+// Transfers complete at once, so they can't be rejected, refunded or disputed.
+func (s *Service) Transfer(fromAccountID int64, toAccountID int64, amount types.Money) (*types.Payment, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	if fromAccountID == toAccountID {
+		return nil, ErrTransferToSelf
+	}
+
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.FindAccountByID(toAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := AccountCurrency(from)
+	if currency != AccountCurrency(to) {
+		return nil, ErrCurrencyMismatch
+	}
+
+	err = s.checkAccount(from, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkAccount(to, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	err = s.checkLimits(fromAccountID, amount, TransferCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := s.feeFor(currency, TransferCategory, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.Available() < total {
+		return nil, ErrNotEnoughBalance
+	}
+
+	fromBalance, err := from.Balance.Sub(total)
+	if err != nil {
+		return nil, err
+	}
+
+	toBalance, err := to.Balance.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := s.limitsOf(currency)
+	if limits.MaxBalance > 0 && toBalance > limits.MaxBalance {
+		return nil, exceeds(LimitMaxBalance, "", limits.MaxBalance, to.Balance, amount)
+	}
+
+	paymentID := s.newID()
+	payment := s.transferPayment(paymentID, fromAccountID, amount, currency, TransferCategory, map[string]string {
+		"transfer":	paymentID,
+		"to":		strconv.FormatInt(toAccountID, 10),
+	})
+
+	if fee > 0 {
+		s.chargeFee(fromAccountID, paymentID, fee, currency, types.PaymentStatusOk)
+	}
+
+	s.transferPayment(s.newID(), toAccountID, amount, currency, TransferCreditCategory, map[string]string {
+		"transfer":	paymentID,
+		"from":		strconv.FormatInt(fromAccountID, 10),
+	})
+
+	from.Balance = fromBalance
+	to.Balance = toBalance
+	s.logger().Log(LevelInfo, "transfer", Op("transfer"), AccountID(fromAccountID), PaymentID(paymentID), Field{ Key: "to", Value: toAccountID })
+
+	return payment, nil
+}
+
+// transferPayment records completed payment of the transfer on one of its accounts
+func (s *Service) transferPayment(id string, accountID int64, amount types.Money, currency types.Currency, category types.PaymentCategory, params map[string]string) *types.Payment {
+	payment := &types.Payment {
+		ID:			id,
+		AccountID:	accountID,
+		Amount:		amount,
+		Category:	category,
+		Status:		types.PaymentStatusOk,
+		CreatedAt:	s.now().Unix(),
+		Params:		params,
+		Currency:	currency,
+	}
+
+	s.payments = append(s.payments, payment)
+	return payment
+}
+
+// repeatTransfer transfers amount of the payment to its target again
+func (s *Service) repeatTransfer(payment *types.Payment) (*types.Payment, error) {
+	toAccountID, err := strconv.ParseInt(payment.Params["to"], 10, 64)
+	if err != nil || transferCredit(payment) {
+		return nil, ErrPaymentNotRepeatable
+	}
+
+	return s.Transfer(payment.AccountID, toAccountID, payment.Amount)
+}
+
+// transferred reports whether the payment was made by a transfer, such payments can't be
+// rejected, refunded or disputed. Params of other payments may come from templates,
+// so the category is checked too
+func transferred(payment *types.Payment) bool {
+	if payment.Category != TransferCategory && payment.Category != TransferCreditCategory {
+		return false
+	}
+
+	return payment.Params["transfer"] != ""
+}
+
+// transferCredit reports whether the payment credits the target account of a transfer,
+// it is history of the account and not spending
+func transferCredit(payment *types.Payment) bool {
+	return transferred(payment) && payment.Category == TransferCreditCategory
+}